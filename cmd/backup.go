@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Rolan335/Musiclib/internal/backup"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+)
+
+// withStorage opens storage and calls action with it, stopped by SIGINT or SIGTERM
+func (f *rootFlags) withStorage(cmd *cobra.Command, action func(ctx context.Context, storage *postgres.Storage, l *logger.Log) error) error {
+	cfg, _, err := f.load(cmd)
+	if err != nil {
+		return err
+	}
+	log, err := logger.NewWithConfig(cfg.Log)
+	if err != nil {
+		return fmt.Errorf("can't create logger: %w", err)
+	}
+	defer log.Close()
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	storage, err := postgres.NewStorage(ctx, &cfg.DB, log)
+	if err != nil {
+		return fmt.Errorf("can't create storage: %w", err)
+	}
+	defer storage.Close()
+	return action(ctx, storage, log)
+}
+
+func newBackupCmd(flags *rootFlags) *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Write consistent snapshot of songs, proposals and api keys to archive",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" {
+				file = "musiclib-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+			}
+			return flags.withStorage(cmd, func(ctx context.Context, storage *postgres.Storage, l *logger.Log) error {
+				//written to temp file first, so failed backup doesn't leave broken archive
+				tmp, err := os.CreateTemp(filepath.Dir(file), ".musiclib-backup-*")
+				if err != nil {
+					return fmt.Errorf("failed to create archive: %w", err)
+				}
+				defer os.Remove(tmp.Name())
+				defer tmp.Close()
+				manifest, err := backup.Backup(ctx, storage, tmp, l)
+				if err != nil {
+					return err
+				}
+				if err := tmp.Close(); err != nil {
+					return fmt.Errorf("failed to write archive: %w", err)
+				}
+				if err := os.Rename(tmp.Name(), file); err != nil {
+					return fmt.Errorf("failed to write archive: %w", err)
+				}
+				fmt.Printf("%s: schema version %d, songs %d, proposals %d, api keys %d\n", file, manifest.SchemaVersion,
+					manifest.Tables["songs"].Rows, manifest.Tables["song_proposals"].Rows, manifest.Tables["api_keys"].Rows)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "archive path, musiclib-<time>.tar.gz if empty")
+	return cmd
+}
+
+func newRestoreCmd(flags *rootFlags) *cobra.Command {
+	opts := backup.RestoreOptions{}
+	cmd := &cobra.Command{
+		Use:   "restore FILE",
+		Short: "Load archive written by backup in one transaction",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return flags.withStorage(cmd, func(ctx context.Context, storage *postgres.Storage, l *logger.Log) error {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open archive: %w", err)
+				}
+				defer f.Close()
+				loaded, err := backup.Restore(ctx, storage, f, opts, l)
+				if err != nil {
+					return err
+				}
+				action := "restored"
+				if opts.DryRun {
+					action = "checked, rolled back"
+				}
+				fmt.Printf("%s %s: songs %d, proposals %d, api keys %d\n", args[0], action,
+					loaded["songs"], loaded["song_proposals"], loaded["api_keys"])
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&opts.OnConflict, "on-conflict", postgres.ConflictFail,
+		"existing rows with the same id: fail, skip, overwrite, or replace to empty tables first")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "load and roll back")
+	_ = cmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions([]string{
+		postgres.ConflictFail, postgres.ConflictSkip, postgres.ConflictOverwrite, postgres.ConflictReplace,
+	}, cobra.ShellCompDirectiveNoFileComp))
+	return cmd
+}
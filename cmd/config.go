@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigCmd(flags *rootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect configuration",
+	}
+	var format string
+	printCmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print effective configuration with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, _, err := flags.load(cmd)
+			if err != nil {
+				return err
+			}
+			return cfg.Print(os.Stdout, format)
+		},
+	}
+	printCmd.Flags().StringVarP(&format, "output", "o", "env", "output format: env or yaml")
+	cmd.AddCommand(printCmd)
+	return cmd
+}
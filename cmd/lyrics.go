@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/musiclib"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+)
+
+func newLyricsCmd(flags *rootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lyrics",
+		Short: "Manage parsed lyrics of songs",
+	}
+	var batchSize int
+	backfillCmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Parse and store sections of songs added before lyrics were parsed on write",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchSize < 1 {
+				return fmt.Errorf("invalid --batch-size %d", batchSize)
+			}
+			return flags.withStorage(cmd, func(ctx context.Context, storage *postgres.Storage, l *logger.Log) error {
+				updated, err := musiclib.NewMusicLib(storage, l).BackfillLyrics(ctx, batchSize)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("parsed lyrics of %d songs\n", updated)
+				return nil
+			})
+		},
+	}
+	backfillCmd.Flags().IntVar(&batchSize, "batch-size", 500, "songs per query")
+	cmd.AddCommand(backfillCmd)
+	return cmd
+}
@@ -1,52 +1,40 @@
-//go:generate oapi-codegen -generate types,models,gin -package api -o ../pkg/api/api.gen.go ../api/swagger/openapi.yaml
-//go:generate oapi-codegen -generate client,models,types -package musicinfo -o ../pkg/musicinfo/api.gen.go ../api/external/musicinfo.yaml
+//go:generate oapi-codegen -generate types,models,gin,spec -package api -o ../pkg/api/api.gen.go ../api/musiclib/openapi.yaml
+//go:generate oapi-codegen -generate client -package api -o ../pkg/api/client.gen.go ../api/musiclib/openapi.yaml
+//go:generate oapi-codegen -generate client,models,types,spec -package musicinfo -o ../pkg/musicinfo/api.gen.go ../api/external/musicinfo.yaml
+//go:generate oapi-codegen -generate gin -package musicinfo -o ../pkg/musicinfo/server.gen.go ../api/external/musicinfo.yaml
 package main
 
 import (
-	"context"
+	"errors"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
 
-	"github.com/Rolan335/Musiclib/internal/app"
 	"github.com/Rolan335/Musiclib/internal/config"
-	"github.com/Rolan335/Musiclib/internal/controller"
-	"github.com/Rolan335/Musiclib/internal/logger"
-	"github.com/Rolan335/Musiclib/internal/musiclib"
 	"github.com/Rolan335/Musiclib/internal/repository/postgres"
 )
 
-func main() {
-	//load config from env
-	cfg := config.MustNewConfig()
-
-	//Initializing logger
-	out := os.Stdout
-	logger := logger.New(cfg.LogLevel, out)
+// exit codes, so orchestrator can tell misconfiguration from unavailable dependency
+const (
+	exitFailure     = 1
+	exitConfig      = 2
+	exitUnavailable = 3
+)
 
-	//making migration
-	if err := postgres.Migrate(&cfg.Migration); err != nil {
-		panic("failed to do migrations: " + err.Error())
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitCode(err))
 	}
+}
 
-	//Initializing postgres storage
-	storage := postgres.MustNewStorage(&cfg.DB, logger)
-
-	//Initializing business logic
-	musiclib := musiclib.NewMusicLib(storage, logger)
-
-	//creating server controller with handlers
-	server := controller.MustNewServer(musiclib, cfg.API.URL, cfg.RequestTimeout)
-
-	//starting http service
-	app := app.NewService(cfg, server, logger)
-
-	//creating notify ctx for graceful shutdown and starting app
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer cancel()
-	app.Start()
-	<-ctx.Done()
-
-	//stopping server and provided services. Provided servies should have method Stop()
-	app.GracefulStop(storage)
+func exitCode(err error) int {
+	var cfgErr *config.Error
+	switch {
+	case errors.As(err, &cfgErr):
+		return exitConfig
+	case errors.Is(err, postgres.ErrUnavailable):
+		return exitUnavailable
+	default:
+		return exitFailure
+	}
 }
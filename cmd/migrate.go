@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+)
+
+func newMigrateCmd(flags *rootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database migrations",
+	}
+
+	// run opens migrator and calls action with it, stopped by SIGINT or SIGTERM.
+	// Actions take migrator first to accept method expressions like (*postgres.Migrator).Up
+	run := func(cmd *cobra.Command, action func(m *postgres.Migrator, ctx context.Context) error) error {
+		cfg, _, err := flags.load(cmd)
+		if err != nil {
+			return err
+		}
+		log, err := logger.NewWithConfig(cfg.Log)
+		if err != nil {
+			return fmt.Errorf("can't create logger: %w", err)
+		}
+		defer log.Close()
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		migrator, err := postgres.NewMigrator(ctx, &cfg.Migration, cfg.DB.Startup, log)
+		if err != nil {
+			return err
+		}
+		defer migrator.Close()
+		return action(migrator, ctx)
+	}
+	simple := func(use, short string, action func(m *postgres.Migrator, ctx context.Context) error) *cobra.Command {
+		return &cobra.Command{
+			Use:   use,
+			Short: short,
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return run(cmd, action)
+			},
+		}
+	}
+	withVersion := func(use, short string, action func(m *postgres.Migrator, ctx context.Context, version int64) error) *cobra.Command {
+		return &cobra.Command{
+			Use:   use + " VERSION",
+			Short: short,
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil || version < 0 {
+					return fmt.Errorf("invalid version %q", args[0])
+				}
+				return run(cmd, func(m *postgres.Migrator, ctx context.Context) error {
+					return action(m, ctx, version)
+				})
+			},
+		}
+	}
+
+	cmd.AddCommand(
+		simple("up", "Apply all pending migrations", (*postgres.Migrator).Up),
+		withVersion("up-to", "Apply pending migrations up to VERSION", (*postgres.Migrator).UpTo),
+		simple("down", "Roll back the last applied migration", (*postgres.Migrator).Down),
+		withVersion("down-to", "Roll back migrations newer than VERSION, 0 rolls back all", (*postgres.Migrator).DownTo),
+		simple("redo", "Roll back and apply again the last migration", (*postgres.Migrator).Redo),
+		simple("status", "Print state of every migration", printStatus),
+		simple("version", "Print version of the last applied migration", func(m *postgres.Migrator, ctx context.Context) error {
+			version, err := m.Version(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Println(version)
+			return nil
+		}),
+		newCreateMigrationCmd(),
+	)
+	return cmd
+}
+
+func printStatus(m *postgres.Migrator, ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if !s.AppliedAt.IsZero() {
+			appliedAt = s.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, appliedAt, s.Source.Path)
+	}
+	return w.Flush()
+}
+
+// create doesn't need database and config, file is written to source directory
+func newCreateMigrationCmd() *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create blank sql migration",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return postgres.CreateMigration(dir, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "migrations", "directory with migrations")
+	return cmd
+}
@@ -0,0 +1,59 @@
+// Local stand-in for the external music info api (api/external/musicinfo.yaml)
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/caarlos0/env/v10"
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/stub"
+	"github.com/Rolan335/Musiclib/pkg/musicinfo"
+)
+
+func main() {
+	cfg := stub.Config{}
+	if err := env.Parse(&cfg); err != nil {
+		logger.New("info", os.Stdout).Error("failed to parse env", "error", err.Error())
+		os.Exit(1)
+	}
+	log := logger.New(cfg.LogLevel, os.Stdout)
+
+	server, err := stub.NewServer(cfg, log)
+	if err != nil {
+		log.Error("failed to create stub", "error", err.Error())
+		os.Exit(1)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(gin.Recovery())
+	musicinfo.RegisterHandlers(r, server)
+	srv := &http.Server{
+		Addr:    cfg.Port,
+		Handler: r,
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("stub is off", "error", err.Error())
+			cancel()
+		}
+	}()
+	log.Info("stub started", "port", cfg.Port, "fixtures", cfg.FixturesDir)
+	<-ctx.Done()
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("Failed to graceful shutdown", "error", err.Error())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+// newClient creates api client authenticated by profile credentials
+func (f *rootFlags) newClient() (*api.ClientWithResponses, error) {
+	p, err := f.resolve()
+	if err != nil {
+		return nil, err
+	}
+	auth := func(ctx context.Context, req *http.Request) error {
+		switch {
+		case p.Token != "":
+			req.Header.Set("Authorization", "Bearer "+p.Token)
+		case p.APIKey != "":
+			req.Header.Set("X-API-Key", p.APIKey)
+		}
+		return nil
+	}
+	client, err := api.NewClientWithResponses(p.Server,
+		api.WithHTTPClient(&http.Client{Timeout: f.timeout}),
+		api.WithRequestEditorFn(auth))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return client, nil
+}
+
+// checkResponse returns problem details of unsuccessful response as error
+func checkResponse(resp *http.Response, body []byte) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	var problem api.Problem
+	if err := json.Unmarshal(body, &problem); err != nil || problem.Code == "" {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s: %s", problem.Status, problem.Code, problem.Title)
+	if problem.Detail != nil {
+		fmt.Fprintf(&b, ": %s", *problem.Detail)
+	}
+	if problem.Errors != nil {
+		for _, e := range *problem.Errors {
+			fmt.Fprintf(&b, "\n  - %s: %s", e.Field, e.Reason)
+		}
+	}
+	if problem.RequestId != nil {
+		fmt.Fprintf(&b, "\nrequest id: %s", *problem.RequestId)
+	}
+	return fmt.Errorf("%s", b.String())
+}
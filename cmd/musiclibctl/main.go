@@ -0,0 +1,57 @@
+// Command line client of the music library api (api/musiclib/openapi.yaml)
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// flags shared by all commands, override values of profile
+type rootFlags struct {
+	profileFile string
+	profile     string
+	server      string
+	apiKey      string
+	token       string
+	output      string
+	timeout     time.Duration
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	flags := &rootFlags{}
+	cmd := &cobra.Command{
+		Use:           "musiclibctl",
+		Short:         "Client of the music library api",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	pf := cmd.PersistentFlags()
+	pf.StringVar(&flags.profileFile, "profiles", defaultProfileFile(), "file with profiles")
+	pf.StringVarP(&flags.profile, "profile", "p", os.Getenv("MUSICLIBCTL_PROFILE"), "profile name, current profile of the file if empty")
+	pf.StringVar(&flags.server, "server", "", "api url, overrides profile")
+	pf.StringVar(&flags.apiKey, "api-key", "", "api key, overrides profile")
+	pf.StringVar(&flags.token, "token", "", "JWT, overrides profile")
+	pf.StringVarP(&flags.output, "output", "o", outputTable, "output format: table, json or yaml")
+	pf.DurationVar(&flags.timeout, "timeout", 10*time.Second, "request timeout")
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		[]string{outputTable, outputJSON, outputYAML}, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		profiles, err := readProfiles(flags.profileFile)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return profiles.names(), cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.AddCommand(newSongsCmd(flags))
+	return cmd
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// profile is a server with credentials of the caller
+type profile struct {
+	Server string `yaml:"server"`
+	APIKey string `yaml:"api_key,omitempty"`
+	Token  string `yaml:"token,omitempty"`
+}
+
+// profiles file, e.g.
+//
+//	current: local
+//	profiles:
+//	  local:
+//	    server: http://localhost:8080
+//	    api_key: ml_...
+type profiles struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+func defaultProfileFile() string {
+	if path := os.Getenv("MUSICLIBCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "musiclibctl", "config.yaml")
+}
+
+// readProfiles reads file, missing file means no profiles
+func readProfiles(path string) (*profiles, error) {
+	result := &profiles{}
+	if path == "" {
+		return result, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	if err := yaml.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+	}
+	return result, nil
+}
+
+func (p *profiles) names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve selects profile and applies flags and MUSICLIB_API_KEY over it
+func (f *rootFlags) resolve() (profile, error) {
+	file, err := readProfiles(f.profileFile)
+	if err != nil {
+		return profile{}, err
+	}
+	name := f.profile
+	if name == "" {
+		name = file.Current
+	}
+	selected := profile{}
+	if name != "" {
+		var ok bool
+		selected, ok = file.Profiles[name]
+		if !ok {
+			return profile{}, fmt.Errorf("profile %q not found in %s", name, f.profileFile)
+		}
+	}
+	if key := os.Getenv("MUSICLIB_API_KEY"); key != "" {
+		selected.APIKey = key
+	}
+	if f.server != "" {
+		selected.Server = f.server
+	}
+	if f.apiKey != "" {
+		selected.APIKey = f.apiKey
+	}
+	if f.token != "" {
+		selected.Token = f.token
+	}
+	if selected.Server == "" {
+		selected.Server = defaultServer
+	}
+	return selected, nil
+}
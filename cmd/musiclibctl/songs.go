@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oapi-codegen/runtime/types"
+	"github.com/spf13/cobra"
+
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+func newSongsCmd(flags *rootFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "songs",
+		Short: "Manage songs of the library",
+	}
+	cmd.AddCommand(
+		newSongsListCmd(flags),
+		newSongsGetCmd(flags),
+		newSongsAddCmd(flags),
+		newSongsEditCmd(flags),
+		newSongsDeleteCmd(flags),
+		newSongsLyricsCmd(flags),
+	)
+	return cmd
+}
+
+// call runs request with client and timeout of flags
+func (f *rootFlags) call(request func(ctx context.Context, client *api.ClientWithResponses) error) error {
+	client, err := f.newClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	return request(ctx, client)
+}
+
+func parseID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid song id %q", arg)
+	}
+	return id, nil
+}
+
+func newSongsListCmd(flags *rootFlags) *cobra.Command {
+	var group, title, text, dateFrom, dateTo string
+	var page, pageSize int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List songs with filters and pagination",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params := api.GetSongsParams{}
+			set := func(name string, value string) *string {
+				if cmd.Flags().Changed(name) {
+					return &value
+				}
+				return nil
+			}
+			params.Group = set("group", group)
+			params.Title = set("title", title)
+			params.Text = set("text", text)
+			for name, value := range map[string]string{"date-from": dateFrom, "date-to": dateTo} {
+				if !cmd.Flags().Changed(name) {
+					continue
+				}
+				parsed, err := time.Parse(time.DateOnly, value)
+				if err != nil {
+					return fmt.Errorf("invalid --%s %q, expected YYYY-MM-DD", name, value)
+				}
+				date := &types.Date{Time: parsed}
+				if name == "date-from" {
+					params.DateFrom = date
+				} else {
+					params.DateTo = date
+				}
+			}
+			if cmd.Flags().Changed("page") {
+				params.Page = &page
+			}
+			if cmd.Flags().Changed("page-size") {
+				params.PageSize = &pageSize
+			}
+			return flags.call(func(ctx context.Context, client *api.ClientWithResponses) error {
+				resp, err := client.GetSongsWithResponse(ctx, &params)
+				if err != nil {
+					return err
+				}
+				if err := checkResponse(resp.HTTPResponse, resp.Body); err != nil {
+					return err
+				}
+				songs := []api.SongGet{}
+				if resp.JSON200 != nil {
+					songs = *resp.JSON200
+				}
+				return write(os.Stdout, flags.output, songs, func(w io.Writer) {
+					fmt.Fprintln(w, "ID\tGROUP\tTITLE\tRELEASE DATE\tLOCKED\tLINK")
+					for _, s := range songs {
+						fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\n", s.Id, s.Group, s.Title, s.ReleaseDate.Format(time.DateOnly), s.Locked, s.Link)
+					}
+				})
+			})
+		},
+	}
+	cmd.Flags().StringVar(&group, "group", "", "filter by group")
+	cmd.Flags().StringVar(&title, "title", "", "filter by title")
+	cmd.Flags().StringVar(&text, "text", "", "search substring in lyrics")
+	cmd.Flags().StringVar(&dateFrom, "date-from", "", "released after date, YYYY-MM-DD")
+	cmd.Flags().StringVar(&dateTo, "date-to", "", "released before date, YYYY-MM-DD")
+	cmd.Flags().IntVar(&page, "page", 1, "page number")
+	cmd.Flags().IntVar(&pageSize, "page-size", 10, "songs per page")
+	return cmd
+}
+
+func newSongsGetCmd(flags *rootFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Show song",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return flags.call(func(ctx context.Context, client *api.ClientWithResponses) error {
+				resp, err := client.GetSongsIdWithResponse(ctx, id)
+				if err != nil {
+					return err
+				}
+				if err := checkResponse(resp.HTTPResponse, resp.Body); err != nil {
+					return err
+				}
+				song := resp.JSON200
+				return write(os.Stdout, flags.output, song, func(w io.Writer) {
+					fmt.Fprintf(w, "ID:\t%d\n", song.Id)
+					fmt.Fprintf(w, "Group:\t%s\n", song.Group)
+					fmt.Fprintf(w, "Title:\t%s\n", song.Title)
+					fmt.Fprintf(w, "Release date:\t%s\n", song.ReleaseDate.Format(time.DateOnly))
+					fmt.Fprintf(w, "Link:\t%s\n", song.Link)
+					fmt.Fprintf(w, "Locked:\t%t\n", song.Locked)
+					fmt.Fprintf(w, "\n%s\n", song.Text)
+				})
+			})
+		},
+	}
+}
+
+func newSongsAddCmd(flags *rootFlags) *cobra.Command {
+	var body api.PostSongsJSONRequestBody
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Add song, details are taken from external api",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return flags.call(func(ctx context.Context, client *api.ClientWithResponses) error {
+				resp, err := client.PostSongsWithResponse(ctx, body)
+				if err != nil {
+					return err
+				}
+				if err := checkResponse(resp.HTTPResponse, resp.Body); err != nil {
+					return err
+				}
+				created := resp.JSON201
+				return write(os.Stdout, flags.output, created, func(w io.Writer) {
+					if created != nil && created.Id != nil {
+						fmt.Fprintf(w, "added song %d\n", *created.Id)
+					}
+				})
+			})
+		},
+	}
+	cmd.Flags().StringVar(&body.Group, "group", "", "group")
+	cmd.Flags().StringVar(&body.Title, "title", "", "title")
+	_ = cmd.MarkFlagRequired("group")
+	_ = cmd.MarkFlagRequired("title")
+	return cmd
+}
+
+func newSongsEditCmd(flags *rootFlags) *cobra.Command {
+	var group, title, releaseDate, text, link string
+	var locked bool
+	cmd := &cobra.Command{
+		Use:   "edit ID",
+		Short: "Change fields of song, only given flags are changed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			patch := api.SongPatch{}
+			set := func(name string, value string) *string {
+				if cmd.Flags().Changed(name) {
+					return &value
+				}
+				return nil
+			}
+			patch.Group = set("group", group)
+			patch.Title = set("title", title)
+			patch.ReleaseDate = set("release-date", releaseDate)
+			patch.Link = set("link", link)
+			if cmd.Flags().Changed("text") {
+				//text from file with @path, from stdin with -
+				value, err := readValue(text)
+				if err != nil {
+					return err
+				}
+				patch.Text = &value
+			}
+			if cmd.Flags().Changed("locked") {
+				patch.Locked = &locked
+			}
+			if patch == (api.SongPatch{}) {
+				return fmt.Errorf("nothing to change, set at least one field flag")
+			}
+			return flags.call(func(ctx context.Context, client *api.ClientWithResponses) error {
+				resp, err := client.PatchSongsIdWithResponse(ctx, id, patch)
+				if err != nil {
+					return err
+				}
+				return checkResponse(resp.HTTPResponse, resp.Body)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&group, "group", "", "group")
+	cmd.Flags().StringVar(&title, "title", "", "title")
+	cmd.Flags().StringVar(&releaseDate, "release-date", "", "release date, DD.MM.YYYY")
+	cmd.Flags().StringVar(&text, "text", "", "lyrics, @file reads file, - reads stdin")
+	cmd.Flags().StringVar(&link, "link", "", "link")
+	cmd.Flags().BoolVar(&locked, "locked", false, "protect from changes by resync")
+	return cmd
+}
+
+// readValue returns content of file for @path, stdin for -, otherwise value itself
+func readValue(value string) (string, error) {
+	var data []byte
+	var err error
+	switch {
+	case value == "-":
+		data, err = io.ReadAll(os.Stdin)
+	case strings.HasPrefix(value, "@"):
+		data, err = os.ReadFile(value[1:])
+	default:
+		return value, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read text: %w", err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+func newSongsDeleteCmd(flags *rootFlags) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID",
+		Short: "Delete song",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return flags.call(func(ctx context.Context, client *api.ClientWithResponses) error {
+				resp, err := client.DeleteSongsIdWithResponse(ctx, id)
+				if err != nil {
+					return err
+				}
+				return checkResponse(resp.HTTPResponse, resp.Body)
+			})
+		},
+	}
+}
+
+func newSongsLyricsCmd(flags *rootFlags) *cobra.Command {
+	var page, pageSize int
+	var section string
+	cmd := &cobra.Command{
+		Use:   "lyrics ID",
+		Short: "Show lyrics of song paginated by verses",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			params := api.GetSongsIdTextParams{Page: &page, PageSize: &pageSize}
+			if section != "" {
+				typ := api.SectionType(section)
+				params.Section = &typ
+			}
+			return flags.call(func(ctx context.Context, client *api.ClientWithResponses) error {
+				resp, err := client.GetSongsIdTextWithResponse(ctx, id, &params)
+				if err != nil {
+					return err
+				}
+				if err := checkResponse(resp.HTTPResponse, resp.Body); err != nil {
+					return err
+				}
+				var verses [][]string
+				if resp.JSON200 != nil {
+					verses = resp.JSON200.Text
+				}
+				return write(os.Stdout, flags.output, resp.JSON200, func(w io.Writer) {
+					for i, verse := range verses {
+						if i > 0 {
+							fmt.Fprintln(w)
+						}
+						for _, line := range verse {
+							fmt.Fprintln(w, line)
+						}
+					}
+				})
+			})
+		},
+	}
+	cmd.Flags().IntVar(&page, "page", 1, "first verse")
+	cmd.Flags().IntVar(&pageSize, "page-size", 100, "number of verses")
+	cmd.Flags().StringVar(&section, "section", "", "only sections of type: intro, verse, pre-chorus, chorus, bridge, outro")
+	return cmd
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Rolan335/Musiclib/internal/config"
+)
+
+// flags shared by all commands
+type rootFlags struct {
+	configFile string
+	envFile    string
+	set        []string
+	port       string
+	logLevel   string
+}
+
+func newRootCmd() *cobra.Command {
+	flags := &rootFlags{}
+	cmd := &cobra.Command{
+		Use:           "musiclib",
+		Short:         "Online music library",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, opts, err := flags.load(cmd)
+			if err != nil {
+				return err
+			}
+			return serve(cfg, opts)
+		},
+	}
+	cmd.PersistentFlags().StringVarP(&flags.configFile, "config", "c", os.Getenv("CONFIG_FILE"), "YAML or TOML config file")
+	cmd.PersistentFlags().StringVar(&flags.envFile, "env-file", ".env", "dotenv file, ignored if missing")
+	cmd.PersistentFlags().StringArrayVar(&flags.set, "set", nil, "override variable, e.g. --set POSTGRES_HOST=localhost")
+	cmd.PersistentFlags().StringVar(&flags.port, "port", "", "listen address, overrides PORT")
+	cmd.PersistentFlags().StringVar(&flags.logLevel, "log-level", "", "log level, overrides LOG_LEVEL")
+	cmd.AddCommand(newConfigCmd(flags), newMigrateCmd(flags), newBackupCmd(flags), newRestoreCmd(flags), newSeedCmd(flags), newLyricsCmd(flags))
+	return cmd
+}
+
+// options converts flags to config sources
+func (f *rootFlags) options(cmd *cobra.Command) (config.Options, error) {
+	overrides := make(map[string]string, len(f.set)+2)
+	for _, kv := range f.set {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return config.Options{}, fmt.Errorf("invalid --set %q, expected KEY=VALUE", kv)
+		}
+		overrides[key] = value
+	}
+	if cmd.Flags().Changed("port") {
+		overrides["PORT"] = f.port
+	}
+	if cmd.Flags().Changed("log-level") {
+		overrides["LOG_LEVEL"] = f.logLevel
+	}
+	return config.Options{
+		File:      f.configFile,
+		EnvFile:   f.envFile,
+		Overrides: overrides,
+	}, nil
+}
+
+// load reads and validates config from sources given by flags
+func (f *rootFlags) load(cmd *cobra.Command) (*config.Config, config.Options, error) {
+	opts, err := f.options(cmd)
+	if err != nil {
+		return nil, opts, err
+	}
+	cfg, err := config.Load(opts)
+	if err != nil {
+		return nil, opts, err
+	}
+	return cfg, opts, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/lyrics"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/seed"
+)
+
+func newSeedCmd(flags *rootFlags) *cobra.Command {
+	var count int
+	var seedValue uint64
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Add synthetic songs for demo and testing, the same seed gives the same songs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if count < 1 {
+				return fmt.Errorf("invalid --count %d", count)
+			}
+			return flags.withStorage(cmd, func(ctx context.Context, storage *postgres.Storage, l *logger.Log) error {
+				generator := seed.NewGenerator(seedValue)
+				left := count
+				copied, err := storage.CopySongs(ctx, func() (entity.Song, bool) {
+					if left == 0 {
+						return entity.Song{}, false
+					}
+					left--
+					song := generator.Song()
+					song.Lyrics = lyrics.Parse(song.Text)
+					return song, true
+				})
+				if err != nil {
+					return err
+				}
+				fmt.Printf("added %d songs, seed %d\n", copied, seedValue)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().IntVarP(&count, "count", "n", 100, "number of songs")
+	cmd.Flags().Uint64Var(&seedValue, "seed", 1, "seed of generator")
+	return cmd
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Rolan335/Musiclib/internal/app"
+	"github.com/Rolan335/Musiclib/internal/auth"
+	"github.com/Rolan335/Musiclib/internal/cassette"
+	"github.com/Rolan335/Musiclib/internal/config"
+	"github.com/Rolan335/Musiclib/internal/controller"
+	"github.com/Rolan335/Musiclib/internal/health"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/logger/redact"
+	"github.com/Rolan335/Musiclib/internal/metrics"
+	"github.com/Rolan335/Musiclib/internal/musiclib"
+	"github.com/Rolan335/Musiclib/internal/ratelimit"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/repository/upstream"
+	"github.com/Rolan335/Musiclib/internal/resync"
+	"github.com/Rolan335/Musiclib/internal/tracing"
+	"github.com/Rolan335/Musiclib/internal/validation"
+	"github.com/Rolan335/Musiclib/pkg/musicinfo"
+)
+
+// serve runs http api until SIGINT or SIGTERM
+func serve(cfg *config.Config, opts config.Options) error {
+	//Initializing logger, sensitive and bulky values are redacted
+	redact.Configure(cfg.LogRedact)
+	logger, err := logger.NewWithConfig(cfg.Log)
+	if err != nil {
+		return fmt.Errorf("can't create logger: %w", err)
+	}
+	defer logger.Close()
+
+	//tracing of requests, exported to collector or file
+	tracer, err := tracing.New(context.Background(), cfg.Tracing, logger)
+	if err != nil {
+		return fmt.Errorf("can't create tracer provider: %w", err)
+	}
+	defer tracer.Close()
+
+	//creating notify ctx for graceful shutdown, it also interrupts waiting for postgres
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	//making migration, postgres may still be starting
+	if err := postgres.Migrate(ctx, &cfg.Migration, cfg.DB.Startup, logger); err != nil {
+		return fmt.Errorf("failed to do migrations: %w", err)
+	}
+
+	//Initializing postgres storage
+	storage, err := postgres.NewStorage(ctx, &cfg.DB, logger)
+	if err != nil {
+		return fmt.Errorf("can't create storage: %w", err)
+	}
+	//closed after server is drained, deferred calls run in reverse order
+	defer storage.Close()
+
+	//Initializing business logic
+	musiclib := musiclib.NewMusicLib(storage, logger)
+
+	//Creating client for external api, exchanges can be recorded to or replayed from cassette
+	recorder, err := cassette.New(cfg.API.Cassette, tracing.HTTPClient())
+	if err != nil {
+		return fmt.Errorf("can't create cassette recorder: %w", err)
+	}
+	extClient, err := musicinfo.NewClient(cfg.API.URL, musicinfo.WithHTTPClient(recorder))
+	if err != nil {
+		return fmt.Errorf("can't create client: %w", err)
+	}
+
+	//validating responses of external api against its contract
+	upstream, err := upstream.New(extClient, cfg.API.Validation)
+	if err != nil {
+		return fmt.Errorf("can't create external api adapter: %w", err)
+	}
+
+	//prometheus metrics of api, postgres pool and external api calls
+	metrics := metrics.New(cfg.Metrics, storage, logger)
+	instrumented := metrics.InstrumentUpstream(upstream)
+
+	//creating server controller with handlers
+	//callers read their own writes from primary while replica catches up
+	stickyPeriod := cfg.DB.Replica.StickyPeriod
+	if cfg.DB.Replica.Host == "" {
+		stickyPeriod = 0
+	}
+	server := controller.NewServer(musiclib, instrumented, logger.Levels(), cfg.RequestTimeout, stickyPeriod)
+
+	//Initializing background resync of songs with external api
+	refresher := resync.NewRefresher(storage, instrumented, cfg.Resync, logger)
+
+	//authentication and role based authorization of api callers
+	authenticator, err := auth.NewAuthenticator(cfg.Auth, storage, logger)
+	if err != nil {
+		return fmt.Errorf("can't create authenticator: %w", err)
+	}
+	authorizer, err := auth.NewAuthorizer(cfg.Auth, logger)
+	if err != nil {
+		return fmt.Errorf("can't create authorizer: %w", err)
+	}
+
+	//per client rate limiting and daily quotas
+	limiter := ratelimit.NewLimiter(cfg.RateLimit, storage, logger)
+
+	//validation of requests and responses against api spec
+	validator, err := validation.NewValidator(cfg.Validation, logger)
+	if err != nil {
+		return fmt.Errorf("can't create validator: %w", err)
+	}
+
+	//liveness and readiness of postgres, migrations and optionally external api
+	latestMigration, err := postgres.LatestMigration(&cfg.Migration)
+	if err != nil {
+		return fmt.Errorf("can't find latest migration: %w", err)
+	}
+	healthcheck := health.New(cfg.Health, logger)
+	healthcheck.Add("postgres", health.Postgres(storage))
+	healthcheck.Add("migrations", health.Migrations(storage, latestMigration))
+	if cfg.Health.CheckUpstream {
+		healthcheck.Add("upstream", health.Upstream(cfg.API.URL, tracing.HTTPClient()))
+	}
+
+	//starting http service
+	app := app.NewService(cfg, server, authenticator, authorizer, limiter, validator, metrics, healthcheck, logger)
+
+	//starting app
+	app.Start()
+	refresher.Start()
+
+	//SIGHUP reloads log levels from config sources
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloaded, err := config.Load(opts)
+			if err == nil {
+				err = logger.Levels().SetFromStrings(reloaded.Log.Level, reloaded.Log.PackageLevels)
+			}
+			if err != nil {
+				logger.Error("failed to reload log levels", "error", err.Error())
+				continue
+			}
+			logger.Info("log levels reloaded", "level", reloaded.Log.Level, "packages", reloaded.Log.PackageLevels)
+		}
+	}()
+	<-ctx.Done()
+
+	//stopping server and background resync, storage and tracer are closed by deferred calls
+	app.GracefulStop(refresher)
+	return nil
+}
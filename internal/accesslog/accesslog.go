@@ -0,0 +1,78 @@
+// Structured access log of http requests
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/problem"
+)
+
+type Config struct {
+	Enabled bool `env:"ACCESS_LOG_ENABLED" envDefault:"true"`
+	// part of successful requests logged, from 0 to 1. Errors and slow requests are always logged
+	SampleRate float64 `env:"ACCESS_LOG_SAMPLE_RATE" envDefault:"1"`
+	// requests longer than this are always logged on lvl warn, 0 disables
+	SlowThreshold time.Duration `env:"ACCESS_LOG_SLOW_THRESHOLD" envDefault:"1s"`
+}
+
+// Middleware logs request after it's served. Request id and caller are added from context by logger.
+// Should be registered after requestid.Middleware
+func Middleware(cfg Config, l *logger.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+		status := c.Writer.Status()
+		slow := cfg.SlowThreshold > 0 && latency >= cfg.SlowThreshold
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest || slow:
+			level = slog.LevelWarn
+		case rand.Float64() >= cfg.SampleRate:
+			return
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("ip", c.ClientIP()),
+		}
+		if slow {
+			attrs = append(attrs, slog.Bool("slow", true))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		l.LogAttrs(c.Request.Context(), level, "access", attrs...)
+	}
+}
+
+// Recovery responds with 500 problem details on panic and logs it with stack
+func Recovery(l *logger.Log) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		l.LogAttrs(c.Request.Context(), slog.LevelError, "panic recovered",
+			slog.String("error", fmt.Sprint(err)),
+			slog.String("stack", string(debug.Stack())),
+		)
+		problem.Abort(c, problem.New(http.StatusInternalServerError, problem.CodeInternal, ""))
+	})
+}
@@ -0,0 +1,146 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+// records decodes json lines written by logger
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var result []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		cfg     Config
+		handler gin.HandlerFunc
+		// empty if request isn't logged
+		level string
+		slow  bool
+	}{
+		{
+			name:    "success",
+			cfg:     Config{Enabled: true, SampleRate: 1},
+			handler: func(c *gin.Context) { c.String(http.StatusOK, "ok") },
+			level:   "INFO",
+		},
+		{
+			name:    "success not sampled",
+			cfg:     Config{Enabled: true, SampleRate: 0},
+			handler: func(c *gin.Context) { c.String(http.StatusOK, "ok") },
+		},
+		{
+			name:    "client error is always logged",
+			cfg:     Config{Enabled: true, SampleRate: 0},
+			handler: func(c *gin.Context) { c.Status(http.StatusNotFound) },
+			level:   "WARN",
+		},
+		{
+			name: "server error is always logged",
+			cfg:  Config{Enabled: true, SampleRate: 0},
+			handler: func(c *gin.Context) {
+				_ = c.Error(errors.New("db is down"))
+				c.Status(http.StatusInternalServerError)
+			},
+			level: "ERROR",
+		},
+		{
+			name: "slow request is always logged",
+			cfg:  Config{Enabled: true, SampleRate: 0, SlowThreshold: time.Millisecond},
+			handler: func(c *gin.Context) {
+				time.Sleep(2 * time.Millisecond)
+				c.Status(http.StatusOK)
+			},
+			level: "WARN",
+			slow:  true,
+		},
+		{
+			name:    "disabled",
+			cfg:     Config{Enabled: false, SampleRate: 1},
+			handler: func(c *gin.Context) { c.Status(http.StatusInternalServerError) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := gin.New()
+			r.Use(Middleware(tt.cfg, logger.New("debug", &buf)))
+			r.GET("/songs/:id", tt.handler)
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/songs/7?page=2", nil))
+
+			logged := records(t, &buf)
+			if tt.level == "" {
+				if len(logged) != 0 {
+					t.Fatalf("logged %v, want nothing", logged)
+				}
+				return
+			}
+			if len(logged) != 1 {
+				t.Fatalf("logged %d records, want 1", len(logged))
+			}
+			record := logged[0]
+			if record["level"] != tt.level || record["msg"] != "access" {
+				t.Errorf("level = %v, msg = %v, want %s access", record["level"], record["msg"], tt.level)
+			}
+			if record["route"] != "/songs/:id" || record["path"] != "/songs/7" || record["method"] != http.MethodGet {
+				t.Errorf("record = %v", record)
+			}
+			if slow, _ := record["slow"].(bool); slow != tt.slow {
+				t.Errorf("slow = %v, want %v", slow, tt.slow)
+			}
+			if tt.level == "ERROR" && !strings.Contains(record["errors"].(string), "db is down") {
+				t.Errorf("errors = %v", record["errors"])
+			}
+		})
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(Recovery(logger.New("debug", &buf)))
+	r.GET("/panic", func(*gin.Context) { panic("nil map") })
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/problem+json") {
+		t.Errorf("Content-Type = %q, want problem details", ct)
+	}
+	logged := records(t, &buf)
+	if len(logged) != 1 || logged[0]["msg"] != "panic recovered" || logged[0]["error"] != "nil map" {
+		t.Fatalf("logged %v", logged)
+	}
+	if stack, _ := logged[0]["stack"].(string); !strings.Contains(stack, "goroutine") {
+		t.Errorf("stack = %q", stack)
+	}
+}
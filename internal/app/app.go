@@ -7,14 +7,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/Rolan335/Musiclib/internal/accesslog"
+	"github.com/Rolan335/Musiclib/internal/auth"
 	"github.com/Rolan335/Musiclib/internal/config"
 	"github.com/Rolan335/Musiclib/internal/controller"
+	"github.com/Rolan335/Musiclib/internal/health"
 	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/metrics"
+	"github.com/Rolan335/Musiclib/internal/ratelimit"
+	"github.com/Rolan335/Musiclib/internal/requestid"
+	"github.com/Rolan335/Musiclib/internal/tracing"
+	"github.com/Rolan335/Musiclib/internal/validation"
 	"github.com/Rolan335/Musiclib/pkg/api"
 )
 
 type Service struct {
 	server *http.Server
+	health *health.Health
 	log    *logger.Log
 }
 
@@ -23,24 +32,44 @@ type Close interface {
 	Close()
 }
 
-func NewService(config *config.Config, server *controller.Server, log *logger.Log) *Service {
+func NewService(config *config.Config, server *controller.Server, authenticator *auth.Authenticator, authorizer *auth.Authorizer, limiter *ratelimit.Limiter, validator *validation.Validator, metrics *metrics.Metrics, health *health.Health, log *logger.Log) *Service {
 	gin.SetMode(config.GinMode)
-	r := gin.Default()
+	r := gin.New()
+	r.Use(
+		requestid.Middleware(),
+		tracing.Middleware(config.Tracing),
+		accesslog.Middleware(config.AccessLog, log),
+		accesslog.Recovery(log),
+		metrics.Middleware(),
+	)
 
 	r.StaticFile("/openapi.yaml", "./api/musiclib/openapi.yaml")
 	r.LoadHTMLGlob("templates/*")
 	r.GET("/swagger", func(c *gin.Context) {
 		c.HTML(200, "swagger.html", nil)
 	})
+	metrics.Register(r)
+	health.Register(r)
 
-	api.RegisterHandlers(r, server)
+	r.Use(validator.ResponseMiddleware())
+	api.RegisterHandlersWithOptions(r, server, api.GinServerOptions{
+		Middlewares: []api.MiddlewareFunc{
+			limiter.IPMiddleware(),
+			authenticator.Middleware(),
+			authorizer.Middleware(),
+			limiter.Middleware(),
+			validator.RequestMiddleware(),
+		},
+		ErrorHandler: controller.ParamErrorHandler,
+	})
 
 	return &Service{
 		server: &http.Server{
 			Addr:    config.Port,
 			Handler: r,
 		},
-		log: log,
+		health: health,
+		log:    log,
 	}
 }
 
@@ -53,15 +82,18 @@ func (s *Service) Start() {
 }
 
 func (s *Service) GracefulStop(services ...interface{}) {
-	for _, service := range services {
-		if asserted, ok := service.(Close); ok {
-			asserted.Close()
-		}
-	}
+	//readiness fails first and stays failing for ShutdownDelay, so load balancers stop routing to stopping instance
+	s.health.Shutdown()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := s.server.Shutdown(ctx); err != nil {
 		s.log.Logger.Error("Failed to graceful shutdown", "error", err.Error())
 	}
+	//services are closed after in-flight requests are drained
+	for _, service := range services {
+		if asserted, ok := service.(Close); ok {
+			asserted.Close()
+		}
+	}
 	s.log.Logger.Info("gracefully shut")
 }
@@ -0,0 +1,187 @@
+// Authentication of api callers with static api keys and jwt bearer tokens
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/problem"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+// header with api key, key can also be passed as "Authorization: ApiKey <key>"
+const APIKeyHeader = "X-API-Key"
+
+type Config struct {
+	// api is closed by default, disabling should be explicit
+	Enabled bool `env:"AUTH_ENABLED" envDefault:"true"`
+	// static key from config, lets create first keys in empty database
+	BootstrapKey string `env:"AUTH_BOOTSTRAP_KEY"`
+	// jwt is validated with HMAC secret or with public keys from JWKS file
+	JWTSecret   string `env:"AUTH_JWT_SECRET"`
+	JWKSFile    string `env:"AUTH_JWKS_FILE"`
+	JWTIssuer   string `env:"AUTH_JWT_ISSUER"`
+	JWTAudience string `env:"AUTH_JWT_AUDIENCE"`
+	// overrides of DefaultPolicy, e.g. "GetProposals=viewer,PostSongs=admin"
+	Policy map[string]string `env:"AUTH_POLICY" envKeyValSeparator:"="`
+}
+
+type KeyStorage interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (entity.APIKey, error)
+}
+
+type Authenticator struct {
+	cfg     Config
+	storage KeyStorage
+	log     *logger.Log
+	jwks    map[string]interface{}
+	parser  *jwt.Parser
+}
+
+func NewAuthenticator(cfg Config, storage KeyStorage, l *logger.Log) (*Authenticator, error) {
+	a := &Authenticator{
+		cfg:     cfg,
+		storage: storage,
+		log:     l,
+	}
+	if cfg.JWKSFile != "" {
+		jwks, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwks = jwks
+	}
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	switch {
+	case a.jwks != nil:
+		opts = append(opts, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}))
+	case cfg.JWTSecret != "":
+		opts = append(opts, jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+	}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+	a.parser = jwt.NewParser(opts...)
+	return a, nil
+}
+
+// Middleware authenticates caller and places Identity on request context.
+// Should be registered with api.RegisterHandlersWithOptions
+func (a *Authenticator) Middleware() api.MiddlewareFunc {
+	return func(c *gin.Context) {
+		if !a.cfg.Enabled {
+			return
+		}
+		identity, err := a.Authenticate(c.Request.Context(), c.Request)
+		if err != nil && !errors.Is(err, ErrInvalidCredentials) {
+			a.log.Standart(c.Request.Context(), "auth: Middleware", c.Request.Method+" "+c.FullPath(), nil, err)
+			problem.Abort(c, problem.New(http.StatusInternalServerError, problem.CodeInternal, ""))
+			return
+		}
+		if err != nil {
+			a.log.BadInput(c.Request.Context(), "auth: Middleware", c.Request.Method+" "+c.FullPath(), err)
+			c.Header("WWW-Authenticate", `Bearer, ApiKey header="`+APIKeyHeader+`"`)
+			problem.Abort(c, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, ErrUnauthorized.Error()))
+			return
+		}
+		ctx := WithIdentity(c.Request.Context(), identity)
+		ctx = logger.WithAttrs(ctx, slog.String("caller", identity.Subject), slog.String("auth_method", identity.Method))
+		c.Request = c.Request.WithContext(ctx)
+	}
+}
+
+// Authenticate checks credentials of the request
+func (a *Authenticator) Authenticate(ctx context.Context, r *http.Request) (Identity, error) {
+	key := r.Header.Get(APIKeyHeader)
+	authorization := r.Header.Get("Authorization")
+	scheme, credentials, _ := strings.Cut(authorization, " ")
+	switch {
+	case key != "":
+		return a.authenticateKey(ctx, key)
+	case strings.EqualFold(scheme, "ApiKey"):
+		return a.authenticateKey(ctx, strings.TrimSpace(credentials))
+	case strings.EqualFold(scheme, "Bearer"):
+		return a.authenticateJWT(strings.TrimSpace(credentials))
+	default:
+		return Identity{}, fmt.Errorf("no credentials provided: %w", ErrInvalidCredentials)
+	}
+}
+
+func (a *Authenticator) authenticateKey(ctx context.Context, key string) (Identity, error) {
+	if a.cfg.BootstrapKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(a.cfg.BootstrapKey)) == 1 {
+		return Identity{Subject: MethodBootstrap, Method: MethodBootstrap, Role: entity.RoleAdmin}, nil
+	}
+	apiKey, err := a.storage.GetAPIKeyByHash(ctx, entity.HashKey(key))
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return Identity{}, fmt.Errorf("failed to find api key: %w: %w", ErrInvalidCredentials, err)
+		}
+		//outage of storage isn't a reason to reject valid key
+		return Identity{}, fmt.Errorf("failed to find api key: %w", err)
+	}
+	role, err := entity.ParseRole(apiKey.Role)
+	if err != nil {
+		return Identity{}, fmt.Errorf("api key %d: %w: %w", apiKey.ID, ErrInvalidCredentials, err)
+	}
+	return Identity{Subject: apiKey.Name, Method: MethodAPIKey, KeyID: apiKey.ID, Role: role}, nil
+}
+
+func (a *Authenticator) authenticateJWT(token string) (Identity, error) {
+	if a.jwks == nil && a.cfg.JWTSecret == "" {
+		return Identity{}, fmt.Errorf("jwt is not configured: %w", ErrInvalidCredentials)
+	}
+	var claims Claims
+	if _, err := a.parser.ParseWithClaims(token, &claims, a.keyFunc); err != nil {
+		return Identity{}, fmt.Errorf("invalid token: %w: %w", ErrInvalidCredentials, err)
+	}
+	if claims.Subject == "" {
+		return Identity{}, fmt.Errorf("token has no subject: %w", ErrInvalidCredentials)
+	}
+	//token without role claim gets the lowest role
+	role := entity.RoleViewer
+	if claims.Role != "" {
+		parsed, err := entity.ParseRole(claims.Role)
+		if err != nil {
+			return Identity{}, fmt.Errorf("invalid role claim: %w: %w", ErrInvalidCredentials, err)
+		}
+		role = parsed
+	}
+	return Identity{Subject: claims.Subject, Method: MethodJWT, Role: role}, nil
+}
+
+func (a *Authenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	if a.jwks == nil {
+		return []byte(a.cfg.JWTSecret), nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := a.jwks[kid]; ok {
+		return key, nil
+	}
+	//token without kid is accepted if jwks has the only key
+	if kid == "" && len(a.jwks) == 1 {
+		for _, key := range a.jwks {
+			return key, nil
+		}
+	}
+	return nil, errors.New("unknown key id " + kid)
+}
+
+// Claims of jwt accepted by api
+type Claims struct {
+	jwt.RegisteredClaims
+	Role string `json:"role,omitempty"`
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+)
+
+const (
+	secret    = "test-secret"
+	bootstrap = "bootstrap-key"
+)
+
+// keyStorage finds keys by hash, err emulates outage of postgres
+type keyStorage struct {
+	keys map[string]entity.APIKey
+	err  error
+}
+
+func (s keyStorage) GetAPIKeyByHash(_ context.Context, hash string) (entity.APIKey, error) {
+	if s.err != nil {
+		return entity.APIKey{}, s.err
+	}
+	key, ok := s.keys[hash]
+	if !ok {
+		return entity.APIKey{}, postgres.ErrNotFound
+	}
+	return key, nil
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func claims(subject string, role string, expires time.Duration) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    "musiclib-test",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expires)),
+		},
+		Role: role,
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	editorKey, editorHash, err := entity.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := keyStorage{keys: map[string]entity.APIKey{
+		editorHash:                {ID: 7, Name: "ci", Role: "editor"},
+		entity.HashKey("mlk_bad"): {ID: 8, Name: "broken", Role: "owner"},
+	}}
+	a, err := NewAuthenticator(Config{
+		Enabled:      true,
+		BootstrapKey: bootstrap,
+		JWTSecret:    secret,
+		JWTIssuer:    "musiclib-test",
+	}, storage, logger.New("error", io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		value   string
+		want    Identity
+		invalid bool
+	}{
+		{name: "api key header", header: APIKeyHeader, value: editorKey, want: Identity{Subject: "ci", Method: MethodAPIKey, KeyID: 7, Role: entity.RoleEditor}},
+		{name: "api key scheme", header: "Authorization", value: "apikey " + editorKey, want: Identity{Subject: "ci", Method: MethodAPIKey, KeyID: 7, Role: entity.RoleEditor}},
+		{name: "bootstrap key", header: APIKeyHeader, value: bootstrap, want: Identity{Subject: MethodBootstrap, Method: MethodBootstrap, Role: entity.RoleAdmin}},
+		{name: "unknown key", header: APIKeyHeader, value: "mlk_unknown", invalid: true},
+		{name: "key with unknown role", header: APIKeyHeader, value: "mlk_bad", invalid: true},
+		{name: "no credentials", invalid: true},
+		{name: "unknown scheme", header: "Authorization", value: "Basic dXNlcjpwYXNz", invalid: true},
+		{
+			name:   "jwt",
+			header: "Authorization",
+			value:  "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(secret), claims("alice", "admin", time.Hour)),
+			want:   Identity{Subject: "alice", Method: MethodJWT, Role: entity.RoleAdmin},
+		},
+		{
+			name:   "jwt without role is viewer",
+			header: "Authorization",
+			value:  "Bearer " + sign(t, jwt.SigningMethodHS384, []byte(secret), claims("bob", "", time.Hour)),
+			want:   Identity{Subject: "bob", Method: MethodJWT, Role: entity.RoleViewer},
+		},
+		{name: "expired jwt", header: "Authorization", value: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(secret), claims("alice", "", -time.Minute)), invalid: true},
+		{name: "jwt of other secret", header: "Authorization", value: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte("other"), claims("alice", "", time.Hour)), invalid: true},
+		{name: "jwt without subject", header: "Authorization", value: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(secret), claims("", "", time.Hour)), invalid: true},
+		{name: "jwt with unknown role", header: "Authorization", value: "Bearer " + sign(t, jwt.SigningMethodHS256, []byte(secret), claims("alice", "root", time.Hour)), invalid: true},
+		{name: "jwt with none alg", header: "Authorization", value: "Bearer " + sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims("alice", "admin", time.Hour)), invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/songs", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			got, err := a.Authenticate(context.Background(), r)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("identity = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateJWKS(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kid": "k1",
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   encode(private.X.FillBytes(make([]byte, 32))),
+		"y":   encode(private.Y.FillBytes(make([]byte, 32))),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAuthenticator(Config{Enabled: true, JWKSFile: path}, keyStorage{}, logger.New("error", io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	r.Header.Set("Authorization", "Bearer "+sign(t, jwt.SigningMethodES256, private, claims("svc", "editor", time.Hour)))
+	got, err := a.Authenticate(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Subject != "svc" || got.Role != entity.RoleEditor {
+		t.Errorf("identity = %+v", got)
+	}
+
+	//HMAC token signed with public key must not pass as asymmetric one
+	r.Header.Set("Authorization", "Bearer "+sign(t, jwt.SigningMethodHS256, []byte(jwks), claims("svc", "admin", time.Hour)))
+	if _, err := a.Authenticate(context.Background(), r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		enabled bool
+		storage keyStorage
+		key     string
+		status  int
+	}{
+		{name: "disabled", enabled: false, status: http.StatusOK},
+		{name: "valid key", enabled: true, key: bootstrap, status: http.StatusOK},
+		{name: "invalid key", enabled: true, key: "mlk_unknown", status: http.StatusUnauthorized},
+		{name: "storage is down", enabled: true, key: "mlk_unknown", storage: keyStorage{err: errors.New("connection refused")}, status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAuthenticator(Config{Enabled: tt.enabled, BootstrapKey: bootstrap}, tt.storage, logger.New("error", io.Discard))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/songs", nil)
+			if tt.key != "" {
+				c.Request.Header.Set(APIKeyHeader, tt.key)
+			}
+
+			a.Middleware()(c)
+			if !c.IsAborted() {
+				c.Status(http.StatusOK)
+			}
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header is missing")
+			}
+			if _, ok := FromContext(c.Request.Context()); ok != (tt.enabled && tt.status == http.StatusOK) {
+				t.Errorf("identity on context = %v", ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package auth
+
+import "errors"
+
+var ErrUnauthorized = errors.New("unauthorized")
+var ErrInvalidCredentials = errors.New("invalid credentials")
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+const (
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+	MethodBootstrap = "bootstrap"
+)
+
+// Identity of the caller, placed on request context by middleware
+type Identity struct {
+	// name of api key or subject of jwt
+	Subject string `json:"subject"`
+	Method  string `json:"method"`
+	// id of api key, 0 for other methods
+	KeyID int         `json:"keyId,omitempty"`
+	Role  entity.Role `json:"role"`
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns identity of the caller, false if request is not authenticated
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// CallerKey identifies caller by api key, jwt subject or ip, e.g. for per-caller limits
+func CallerKey(c *gin.Context) string {
+	identity, ok := FromContext(c.Request.Context())
+	switch {
+	case ok && identity.Method == MethodAPIKey:
+		return "key:" + strconv.Itoa(identity.KeyID)
+	case ok:
+		return identity.Method + ":" + identity.Subject
+	default:
+		return "ip:" + c.ClientIP()
+	}
+}
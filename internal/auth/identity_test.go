@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+func TestCallerKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		identity *Identity
+		want     string
+	}{
+		{name: "anonymous", want: "ip:192.0.2.1"},
+		{name: "api key", identity: &Identity{Subject: "ci", Method: MethodAPIKey, KeyID: 3, Role: entity.RoleEditor}, want: "key:3"},
+		{name: "jwt", identity: &Identity{Subject: "alice", Method: MethodJWT, Role: entity.RoleViewer}, want: "jwt:alice"},
+		{name: "bootstrap", identity: &Identity{Subject: MethodBootstrap, Method: MethodBootstrap, Role: entity.RoleAdmin}, want: "bootstrap:bootstrap"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/songs", nil)
+			c.Request.RemoteAddr = "192.0.2.1:5555"
+			if tt.identity != nil {
+				c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), *tt.identity))
+			}
+			if got := CallerKey(c); got != tt.want {
+				t.Errorf("CallerKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/operation"
+	"github.com/Rolan335/Musiclib/internal/problem"
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+// Policy maps operations of api.ServerInterface to minimal role required to call them
+type Policy map[string]entity.Role
+
+// DefaultPolicy is applied if config doesn't override operation.
+// Operations missing in policy are allowed to admins only
+var DefaultPolicy = Policy{
+	"GetSongs":       entity.RoleViewer,
+	"GetSongsId":     entity.RoleViewer,
+	"GetSongsIdText": entity.RoleViewer,
+
+	"PostSongs":    entity.RoleEditor,
+	"PatchSongsId": entity.RoleEditor,
+
+	"GetProposals":         entity.RoleEditor,
+	"PostProposalsIdApply": entity.RoleEditor,
+	"DeleteProposalsId":    entity.RoleEditor,
+
+	"DeleteSongsId":    entity.RoleAdmin,
+	"GetAuthKeys":      entity.RoleAdmin,
+	"PostAuthKeys":     entity.RoleAdmin,
+	"DeleteAuthKeysId": entity.RoleAdmin,
+	"GetAdminLogLevel": entity.RoleAdmin,
+	"PutAdminLogLevel": entity.RoleAdmin,
+}
+
+type Authorizer struct {
+	enabled bool
+	policy  Policy
+	log     *logger.Log
+}
+
+// NewAuthorizer merges cfg.Policy overrides into DefaultPolicy
+func NewAuthorizer(cfg Config, l *logger.Log) (*Authorizer, error) {
+	policy := make(Policy, len(DefaultPolicy))
+	for op, role := range DefaultPolicy {
+		policy[op] = role
+	}
+	for op, s := range cfg.Policy {
+		role, err := entity.ParseRole(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy for %s: %w", op, err)
+		}
+		policy[strings.TrimSpace(op)] = role
+	}
+	return &Authorizer{
+		enabled: cfg.Enabled,
+		policy:  policy,
+		log:     l,
+	}, nil
+}
+
+// Middleware checks role of identity placed by Authenticator. Should be registered after Authenticator.Middleware
+func (a *Authorizer) Middleware() api.MiddlewareFunc {
+	return func(c *gin.Context) {
+		if !a.enabled {
+			return
+		}
+		op := operation.Name(c)
+		identity, _ := FromContext(c.Request.Context())
+		allowed, reason := a.Authorize(identity, op)
+		a.log.LogAttrs(c.Request.Context(), slog.LevelInfo, "auth: authorization decision",
+			slog.String("operation", op),
+			slog.String("subject", identity.Subject),
+			slog.String("role", string(identity.Role)),
+			slog.Bool("allowed", allowed),
+			slog.String("reason", reason),
+		)
+		if !allowed {
+			problem.Abort(c, problem.New(http.StatusForbidden, problem.CodeForbidden, reason))
+		}
+	}
+}
+
+// Authorize returns whether identity may call operation and the reason of decision
+func (a *Authorizer) Authorize(identity Identity, op string) (bool, string) {
+	required, ok := a.policy[op]
+	if !ok {
+		required = entity.RoleAdmin
+	}
+	if !identity.Role.Allows(required) {
+		role := string(identity.Role)
+		if role == "" {
+			role = "none"
+		}
+		return false, fmt.Sprintf("operation %s requires role %s, caller has role %s", op, required, role)
+	}
+	return true, fmt.Sprintf("role %s allows operation %s", identity.Role, op)
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+func TestAuthorize(t *testing.T) {
+	a, err := NewAuthorizer(Config{Enabled: true, Policy: map[string]string{
+		" GetProposals ": "Viewer",
+		"GetSongs":       "editor",
+	}}, logger.New("error", io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer := Identity{Subject: "v", Role: entity.RoleViewer}
+	editor := Identity{Subject: "e", Role: entity.RoleEditor}
+	admin := Identity{Subject: "a", Role: entity.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		identity Identity
+		op       string
+		allowed  bool
+	}{
+		{"viewer reads text", viewer, "GetSongsIdText", true},
+		{"viewer can't add song", viewer, "PostSongs", false},
+		{"editor adds song", editor, "PostSongs", true},
+		{"editor can't delete song", editor, "DeleteSongsId", false},
+		{"admin deletes song", admin, "DeleteSongsId", true},
+		{"override lowers role", viewer, "GetProposals", true},
+		{"override raises role", viewer, "GetSongs", false},
+		{"override allows higher role", admin, "GetSongs", true},
+		{"operation without rule is for admins", editor, "UnknownOperation", false},
+		{"admin calls operation without rule", admin, "UnknownOperation", true},
+		{"anonymous", Identity{}, "GetSongs", false},
+		{"unknown role", Identity{Role: "owner"}, "GetSongsIdText", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, reason := a.Authorize(tt.identity, tt.op)
+			if allowed != tt.allowed {
+				t.Errorf("Authorize() = %v (%s), want %v", allowed, reason, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestNewAuthorizerInvalidPolicy(t *testing.T) {
+	_, err := NewAuthorizer(Config{Policy: map[string]string{"GetSongs": "root"}}, logger.New("error", io.Discard))
+	if !errors.Is(err, entity.ErrInvalidRole) {
+		t.Errorf("err = %v, want ErrInvalidRole", err)
+	}
+}
+
+func TestAuthorizerMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		enabled  bool
+		identity *Identity
+		status   int
+	}{
+		{name: "disabled", enabled: false, status: http.StatusOK},
+		{name: "not authenticated", enabled: true, status: http.StatusForbidden},
+		{name: "not enough role", enabled: true, identity: &Identity{Subject: "e", Role: entity.RoleEditor}, status: http.StatusForbidden},
+		{name: "admin", enabled: true, identity: &Identity{Subject: "a", Role: entity.RoleAdmin}, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewAuthorizer(Config{Enabled: tt.enabled}, logger.New("error", io.Discard))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := gin.New()
+			//route isn't in policy, so only admins pass
+			r.GET("/internal", func(c *gin.Context) {
+				if tt.identity != nil {
+					c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), *tt.identity))
+				}
+				c.Next()
+			}, gin.HandlerFunc(a.Middleware()), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
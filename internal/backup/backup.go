@@ -0,0 +1,289 @@
+// Portable snapshots of the song catalogue: gzipped tar with manifest and a JSON Lines file per table
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/lyrics"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+)
+
+// FormatVersion is incremented on incompatible changes of archive layout
+const FormatVersion = 1
+
+const manifestName = "manifest.json"
+
+// tables in order of restore, referenced tables first
+var tables = []string{"songs", "song_proposals", "api_keys"}
+
+var (
+	ErrFormat        = errors.New("invalid backup archive")
+	ErrSchemaVersion = errors.New("schema version of backup differs from database")
+)
+
+type Manifest struct {
+	FormatVersion int                  `json:"format_version"`
+	SchemaVersion int64                `json:"schema_version"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Tables        map[string]TableInfo `json:"tables"`
+}
+
+type TableInfo struct {
+	File   string `json:"file"`
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// Storage is postgres storage with snapshot and restore support
+type Storage interface {
+	Snapshot(ctx context.Context, fn func(snap *postgres.Snapshot) error) error
+	Restore(ctx context.Context, policy string, dryRun bool, fn func(l *postgres.Loader) error) error
+}
+
+// Backup writes archive of all tables read in one transaction
+func Backup(ctx context.Context, storage Storage, w io.Writer, l *logger.Log) (*Manifest, error) {
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		Tables:        make(map[string]TableInfo, len(tables)),
+	}
+	//tables are spooled to temp files, so manifest with counts goes first in archive
+	files := make(map[string]*os.File, len(tables))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	err := storage.Snapshot(ctx, func(snap *postgres.Snapshot) error {
+		version, err := snap.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		manifest.SchemaVersion = version
+		for _, table := range tables {
+			f, err := os.CreateTemp("", "musiclib-backup-*.jsonl")
+			if err != nil {
+				return fmt.Errorf("failed to create temp file: %w", err)
+			}
+			files[table] = f
+			enc := newTableWriter(f)
+			switch table {
+			case "songs":
+				err = snap.Songs(ctx, func(row postgres.SongRow) error { return enc.write(row) })
+			case "song_proposals":
+				err = snap.Proposals(ctx, func(row postgres.ProposalRow) error { return enc.write(row) })
+			case "api_keys":
+				err = snap.APIKeys(ctx, func(row postgres.APIKeyRow) error { return enc.write(row) })
+			}
+			if err != nil {
+				return fmt.Errorf("failed to dump %s: %w", table, err)
+			}
+			if err := enc.flush(); err != nil {
+				return fmt.Errorf("failed to write %s: %w", table, err)
+			}
+			manifest.Tables[table] = TableInfo{File: table + ".jsonl", Rows: enc.rows, SHA256: hex.EncodeToString(enc.sum.Sum(nil))}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := writeEntry(tw, manifestName, int64(len(data)), manifest.CreatedAt, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		f := files[table]
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat temp file: %w", err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind temp file: %w", err)
+		}
+		if err := writeEntry(tw, manifest.Tables[table].File, info.Size(), manifest.CreatedAt, f); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	l.Info("backup: written", "schema_version", manifest.SchemaVersion, "tables", manifest.Tables)
+	return manifest, nil
+}
+
+// RestoreOptions control loading of archive
+type RestoreOptions struct {
+	// postgres.Conflict* policy
+	OnConflict string
+	// load and roll back, to check archive against database
+	DryRun bool
+}
+
+// Restore loads archive in one transaction. Schema version of database should match the archive
+func Restore(ctx context.Context, storage Storage, r io.Reader, opts RestoreOptions, l *logger.Log) (loaded map[string]int, err error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFormat, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	manifest, err := readManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+	err = storage.Restore(ctx, opts.OnConflict, opts.DryRun, func(loader *postgres.Loader) error {
+		version, err := loader.SchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version != manifest.SchemaVersion {
+			return fmt.Errorf("%w: backup %d, database %d, run musiclib migrate up-to %d",
+				ErrSchemaVersion, manifest.SchemaVersion, version, manifest.SchemaVersion)
+		}
+		for _, table := range tables {
+			info, ok := manifest.Tables[table]
+			if !ok {
+				return fmt.Errorf("%w: table %s is missing in manifest", ErrFormat, table)
+			}
+			header, err := tr.Next()
+			if err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrFormat, info.File, err)
+			}
+			if header.Name != info.File {
+				return fmt.Errorf("%w: expected %s, found %s", ErrFormat, info.File, header.Name)
+			}
+			dec := newTableReader(tr)
+			switch table {
+			case "songs":
+				err = readRows(dec, func(row postgres.SongRow) error {
+					row.Lyrics = lyrics.Parse(row.Text)
+					return loader.Song(ctx, row)
+				})
+			case "song_proposals":
+				err = readRows(dec, func(row postgres.ProposalRow) error { return loader.Proposal(ctx, row) })
+			case "api_keys":
+				err = readRows(dec, func(row postgres.APIKeyRow) error { return loader.APIKey(ctx, row) })
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore %s: %w", table, err)
+			}
+			if dec.rows != info.Rows || hex.EncodeToString(dec.sum.Sum(nil)) != info.SHA256 {
+				return fmt.Errorf("%w: %s doesn't match manifest", ErrFormat, info.File)
+			}
+		}
+		loaded = loader.Loaded
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.Info("backup: restored", "schema_version", manifest.SchemaVersion, "created_at", manifest.CreatedAt,
+		"policy", opts.OnConflict, "dry_run", opts.DryRun, "loaded", loaded)
+	return loaded, nil
+}
+
+func readManifest(tr *tar.Reader) (*Manifest, error) {
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFormat, err)
+	}
+	if header.Name != manifestName {
+		return nil, fmt.Errorf("%w: %s should be the first entry", ErrFormat, manifestName)
+	}
+	manifest := &Manifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("%w: manifest: %w", ErrFormat, err)
+	}
+	if manifest.FormatVersion != FormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrFormat, manifest.FormatVersion)
+	}
+	return manifest, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: modTime}); err != nil {
+		return fmt.Errorf("failed to write %s header: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// tableWriter encodes rows as JSON Lines counting rows and checksum
+type tableWriter struct {
+	buf  *bufio.Writer
+	enc  *json.Encoder
+	sum  hash.Hash
+	rows int
+}
+
+func newTableWriter(w io.Writer) *tableWriter {
+	sum := sha256.New()
+	buf := bufio.NewWriter(io.MultiWriter(w, sum))
+	return &tableWriter{buf: buf, enc: json.NewEncoder(buf), sum: sum}
+}
+
+func (t *tableWriter) write(row any) error {
+	t.rows++
+	return t.enc.Encode(row)
+}
+
+func (t *tableWriter) flush() error {
+	return t.buf.Flush()
+}
+
+// tableReader decodes JSON Lines counting rows and checksum
+type tableReader struct {
+	dec  *json.Decoder
+	sum  hash.Hash
+	rows int
+}
+
+func newTableReader(r io.Reader) *tableReader {
+	sum := sha256.New()
+	return &tableReader{dec: json.NewDecoder(io.TeeReader(r, sum)), sum: sum}
+}
+
+func readRows[T any](t *tableReader, fn func(row T) error) error {
+	for {
+		var row T
+		err := t.dec.Decode(&row)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: row %d: %w", ErrFormat, t.rows+1, err)
+		}
+		t.rows++
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
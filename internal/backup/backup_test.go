@@ -0,0 +1,243 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v10"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+)
+
+// name of throwaway database for round-trip tests, its tables are dropped and recreated.
+// Other connection settings are taken from POSTGRES_* variables
+const testDBVar = "MUSICLIB_TEST_DB"
+
+// testDB connects to test database and migrates it from scratch on every call
+type testDB struct {
+	t        *testing.T
+	storage  *postgres.Storage
+	migrator *postgres.Migrator
+}
+
+func newTestDB(t *testing.T) *testDB {
+	t.Helper()
+	name := os.Getenv(testDBVar)
+	if name == "" {
+		t.Skipf("%s is not set", testDBVar)
+	}
+	var cfg postgres.Config
+	if err := env.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Name = name
+	cfg.Startup.Timeout = 5 * time.Second
+	ctx := context.Background()
+	l := logger.New("error", io.Discard)
+	migrator, err := postgres.NewMigrator(ctx, &postgres.MigrationConfig{Driver: "postgres", ConnStr: cfg.ConnString()}, cfg.Startup, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(migrator.Close)
+	storage, err := postgres.NewStorage(ctx, &cfg, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(storage.Close)
+	return &testDB{t: t, storage: storage, migrator: migrator}
+}
+
+// reset leaves empty tables of the latest schema
+func (db *testDB) reset() {
+	db.t.Helper()
+	ctx := context.Background()
+	if err := db.migrator.DownTo(ctx, 0); err != nil {
+		db.t.Fatal(err)
+	}
+	if err := db.migrator.Up(ctx); err != nil {
+		db.t.Fatal(err)
+	}
+}
+
+func (db *testDB) seed() {
+	db.t.Helper()
+	ctx := context.Background()
+	date := time.Date(2006, time.July, 16, 0, 0, 0, 0, time.UTC)
+	text := "[Verse]\nOoh baby, don't you know I suffer?\n\n[Chorus]\nSupermassive black hole"
+	id, err := db.storage.CreateSong(ctx, entity.Song{Group: "Muse", Title: "Supermassive Black Hole", ReleaseDate: date, Text: text, Link: "https://example.com/1"})
+	if err != nil {
+		db.t.Fatal(err)
+	}
+	if _, err := db.storage.CreateSong(ctx, entity.Song{Group: "Muse", Title: "Uprising", ReleaseDate: date, Text: "Paranoia is in bloom", Link: "https://example.com/2"}); err != nil {
+		db.t.Fatal(err)
+	}
+	fresh := "Supermassive black hole\n\nla la"
+	if _, err := db.storage.UpsertProposal(ctx, entity.Proposal{SongID: id, Text: &fresh}); err != nil {
+		db.t.Fatal(err)
+	}
+	if _, err := db.storage.CreateAPIKey(ctx, entity.APIKey{Name: "ci", Role: "editor", Hash: entity.HashKey("mlk_test")}); err != nil {
+		db.t.Fatal(err)
+	}
+}
+
+func (db *testDB) backup() (*bytes.Buffer, *Manifest) {
+	db.t.Helper()
+	var buf bytes.Buffer
+	manifest, err := Backup(context.Background(), db.storage, &buf, logger.New("error", io.Discard))
+	if err != nil {
+		db.t.Fatal(err)
+	}
+	return &buf, manifest
+}
+
+func (db *testDB) restore(archive []byte, policy string) (map[string]int, error) {
+	return Restore(context.Background(), db.storage, bytes.NewReader(archive), RestoreOptions{OnConflict: policy}, logger.New("error", io.Discard))
+}
+
+func TestRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	db.reset()
+	db.seed()
+	data, original := db.backup()
+	all := map[string]int{"songs": 2, "song_proposals": 1, "api_keys": 1}
+
+	policies := []string{postgres.ConflictFail, postgres.ConflictSkip, postgres.ConflictOverwrite, postgres.ConflictReplace}
+	for _, policy := range policies {
+		t.Run("empty database "+policy, func(t *testing.T) {
+			db.reset()
+			loaded, err := db.restore(data.Bytes(), policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loaded, all) {
+				t.Errorf("loaded = %v, want %v", loaded, all)
+			}
+			_, restored := db.backup()
+			if !reflect.DeepEqual(restored.Tables, original.Tables) {
+				t.Errorf("tables after restore = %v, want %v", restored.Tables, original.Tables)
+			}
+			song, err := db.storage.GetSong(context.Background(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(song.Lyrics) != 2 || song.Lyrics[1].Type != entity.SectionChorus {
+				t.Errorf("lyrics = %+v, want parsed verse and chorus", song.Lyrics)
+			}
+			//sequences continue after restored ids
+			id, err := db.storage.CreateSong(context.Background(), entity.Song{Group: "Muse", Title: "Starlight", Text: "Far away"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != 3 {
+				t.Errorf("id of new song = %d, want 3", id)
+			}
+		})
+	}
+
+	tests := []struct {
+		policy string
+		loaded map[string]int
+		err    error
+		// songs in database after restore
+		songs int
+	}{
+		{policy: postgres.ConflictFail, err: postgres.ErrConflict, songs: 3},
+		//proposals of kept songs aren't loaded at all
+		{policy: postgres.ConflictSkip, loaded: map[string]int{"songs": 0, "api_keys": 0}, songs: 3},
+		{policy: postgres.ConflictOverwrite, loaded: all, songs: 3},
+		//song added after backup is removed with its proposals
+		{policy: postgres.ConflictReplace, loaded: all, songs: 2},
+	}
+	for _, tt := range tests {
+		t.Run("existing rows "+tt.policy, func(t *testing.T) {
+			db.reset()
+			db.seed()
+			extra, err := db.storage.CreateSong(context.Background(), entity.Song{Group: "Muse", Title: "Starlight", Text: "Far away"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := "Far away\n\nfrom home"
+			if _, err := db.storage.UpsertProposal(context.Background(), entity.Proposal{SongID: extra, Text: &text}); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := db.restore(data.Bytes(), tt.policy)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(loaded, tt.loaded) {
+				t.Errorf("loaded = %v, want %v", loaded, tt.loaded)
+			}
+			count, err := db.storage.CountSongs(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.songs {
+				t.Errorf("songs = %d, want %d", count, tt.songs)
+			}
+		})
+	}
+
+	t.Run("schema version differs", func(t *testing.T) {
+		db.reset()
+		if err := db.migrator.Down(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(db.reset)
+		if _, err := db.restore(data.Bytes(), postgres.ConflictReplace); !errors.Is(err, ErrSchemaVersion) {
+			t.Errorf("err = %v, want ErrSchemaVersion", err)
+		}
+	})
+}
+
+// archive builds tar.gz of entries in order
+func archive(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		if err := writeEntry(tw, e[0], int64(len(e[1])), time.Now(), bytes.NewReader([]byte(e[1]))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// archive is checked before storage is touched, so nil storage is enough
+func TestRestoreInvalidArchive(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not gzip", data: []byte("songs.jsonl")},
+		{name: "empty archive", data: archive(t)},
+		{name: "manifest is not first", data: archive(t, [2]string{"songs.jsonl", "{}\n"}, [2]string{manifestName, "{}"})},
+		{name: "invalid manifest", data: archive(t, [2]string{manifestName, "{"})},
+		{name: "unsupported format", data: archive(t, [2]string{manifestName, `{"format_version": 99}`})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Restore(context.Background(), nil, bytes.NewReader(tt.data), RestoreOptions{}, logger.New("error", io.Discard))
+			if !errors.Is(err, ErrFormat) {
+				t.Errorf("err = %v, want ErrFormat", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Record/replay of http exchanges with external api.
+// Recorder implements musicinfo.HttpRequestDoer, so it can be passed to client with musicinfo.WithHTTPClient
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Rolan335/Musiclib/pkg/musicinfo"
+)
+
+const (
+	// requests go to external api, nothing is recorded
+	ModeOff = "off"
+	// requests go to external api, exchanges are appended to cassette
+	ModeRecord = "record"
+	// requests are served from cassette only
+	ModeReplay = "replay"
+	// requests are served from cassette, missing ones are recorded
+	ModeReplayOrRecord = "replay_or_record"
+)
+
+// value stored instead of redacted headers and query params
+const Redacted = "REDACTED"
+
+var ErrNoInteraction = errors.New("no recorded interaction matches request")
+
+type Config struct {
+	Mode          string   `env:"CASSETTE_MODE"`
+	Path          string   `env:"CASSETTE_PATH"`
+	RedactHeaders []string `env:"CASSETTE_REDACT_HEADERS" envSeparator:","`
+	RedactQuery   []string `env:"CASSETTE_REDACT_QUERY" envSeparator:","`
+}
+
+// Cassette is the content of cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   map[string][]string `json:"query,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+}
+
+// Response of external api. If Error is not empty, exchange failed on transport level
+type Response struct {
+	Status  int                 `json:"status,omitempty"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    string              `json:"body,omitempty"`
+	Error   string              `json:"error,omitempty"`
+}
+
+type Recorder struct {
+	cfg  Config
+	next musicinfo.HttpRequestDoer
+
+	mu       sync.Mutex
+	cassette Cassette
+	// count of replays per interaction index, identical requests are replayed in recorded order
+	played map[int]int
+}
+
+var _ musicinfo.HttpRequestDoer = (*Recorder)(nil)
+
+// New loads cassette from cfg.Path. next is used to reach external api in record modes, http.DefaultClient if nil
+func New(cfg Config, next musicinfo.HttpRequestDoer) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultClient
+	}
+	cfg.Mode = strings.ToLower(cfg.Mode)
+	switch cfg.Mode {
+	case ModeOff, ModeRecord, ModeReplay, ModeReplayOrRecord:
+	case "":
+		cfg.Mode = ModeOff
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", cfg.Mode)
+	}
+	if cfg.Mode != ModeOff && cfg.Path == "" {
+		return nil, errors.New("cassette path is empty")
+	}
+	if len(cfg.RedactHeaders) == 0 {
+		cfg.RedactHeaders = []string{"Authorization", "Cookie", "X-Api-Key"}
+	}
+	r := &Recorder{
+		cfg:    cfg,
+		next:   next,
+		played: make(map[int]int),
+	}
+	if cfg.Mode == ModeOff {
+		return r, nil
+	}
+	data, err := os.ReadFile(cfg.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && cfg.Mode != ModeReplay:
+	case err != nil:
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	default:
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	switch r.cfg.Mode {
+	case ModeOff:
+		return r.next.Do(req)
+	case ModeRecord:
+		return r.record(req)
+	}
+	recorded := r.toRequest(req)
+	r.mu.Lock()
+	interaction, ok := r.find(recorded)
+	r.mu.Unlock()
+	if ok {
+		return interaction.Response.toHTTP(req)
+	}
+	if r.cfg.Mode == ModeReplay {
+		return nil, fmt.Errorf("%s %s?%s: %w", req.Method, req.URL.Path, req.URL.RawQuery, ErrNoInteraction)
+	}
+	return r.record(req)
+}
+
+// record sends request to external api and appends exchange to cassette
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	interaction := Interaction{Request: r.toRequest(req)}
+	resp, err := r.next.Do(req)
+	if err != nil {
+		interaction.Response.Error = err.Error()
+		return nil, errors.Join(err, r.append(interaction))
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	interaction.Response = Response{
+		Status:  resp.StatusCode,
+		Headers: r.redactHeaders(resp.Header),
+		Body:    string(body),
+	}
+	if err := r.append(interaction); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) append(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	// mark as played, so replay_or_record doesn't serve it to the next identical request
+	r.played[len(r.cassette.Interactions)-1]++
+	return r.save()
+}
+
+// save writes cassette to file. Should be called with mu locked
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.cfg.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette dir: %w", err)
+	}
+	if err := os.WriteFile(r.cfg.Path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// find returns first not yet played interaction matching request.
+// When all matching are played, the last one is repeated. Should be called with mu locked
+func (r *Recorder) find(req Request) (Interaction, bool) {
+	last := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !r.match(interaction.Request, req) {
+			continue
+		}
+		last = i
+		if r.played[i] == 0 {
+			r.played[i]++
+			return interaction, true
+		}
+	}
+	if last == -1 {
+		return Interaction{}, false
+	}
+	r.played[last]++
+	return r.cassette.Interactions[last], true
+}
+
+// match compares method, path and query params ignoring their order
+func (r *Recorder) match(recorded Request, req Request) bool {
+	if recorded.Method != req.Method || recorded.Path != req.Path || len(recorded.Query) != len(req.Query) {
+		return false
+	}
+	for key, values := range req.Query {
+		recordedValues, ok := recorded.Query[key]
+		if !ok || !slices.Equal(sorted(recordedValues), sorted(values)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) toRequest(req *http.Request) Request {
+	query := make(map[string][]string)
+	for key, values := range req.URL.Query() {
+		if r.redacted(r.cfg.RedactQuery, key) {
+			query[key] = []string{Redacted}
+			continue
+		}
+		query[key] = values
+	}
+	return Request{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Query:   query,
+		Headers: r.redactHeaders(req.Header),
+	}
+}
+
+func (r *Recorder) redactHeaders(headers http.Header) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	redacted := make(map[string][]string, len(headers))
+	for key, values := range headers {
+		if r.redacted(r.cfg.RedactHeaders, key) {
+			redacted[key] = []string{Redacted}
+			continue
+		}
+		redacted[key] = values
+	}
+	return redacted
+}
+
+func (r *Recorder) redacted(keys []string, key string) bool {
+	return slices.ContainsFunc(keys, func(k string) bool {
+		return strings.EqualFold(strings.TrimSpace(k), key)
+	})
+}
+
+func (resp Response) toHTTP(req *http.Request) (*http.Response, error) {
+	if resp.Error != "" {
+		return nil, fmt.Errorf("recorded error: %s", resp.Error)
+	}
+	header := make(http.Header, len(resp.Headers))
+	for key, values := range resp.Headers {
+		header[key] = values
+	}
+	//recorded length can differ from body edited in cassette
+	header.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}, nil
+}
+
+func sorted(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return values
+}
@@ -0,0 +1,71 @@
+package cassette
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRecorderFind(t *testing.T) {
+	r := &Recorder{
+		cassette: Cassette{Interactions: []Interaction{
+			{Request: Request{Method: "GET", Path: "/info", Query: map[string][]string{"group": {"Muse"}, "song": {"Uprising"}}}, Response: Response{Status: 500}},
+			{Request: Request{Method: "GET", Path: "/info", Query: map[string][]string{"group": {"Muse"}, "song": {"Uprising"}}}, Response: Response{Status: 200}},
+			{Request: Request{Method: "GET", Path: "/info", Query: map[string][]string{"group": {"Muse"}, "song": {"Hysteria"}}}, Response: Response{Status: 404}},
+		}},
+		played: make(map[int]int),
+	}
+	uprising := Request{Method: "GET", Path: "/info", Query: map[string][]string{"song": {"Uprising"}, "group": {"Muse"}}}
+
+	tests := []struct {
+		name   string
+		req    Request
+		status int
+		found  bool
+	}{
+		{name: "first recorded", req: uprising, status: 500, found: true},
+		{name: "next recorded", req: uprising, status: 200, found: true},
+		{name: "last repeated", req: uprising, status: 200, found: true},
+		{name: "other song", req: Request{Method: "GET", Path: "/info", Query: map[string][]string{"group": {"Muse"}, "song": {"Hysteria"}}}, status: 404, found: true},
+		{name: "other method", req: Request{Method: "POST", Path: "/info", Query: uprising.Query}},
+		{name: "extra param", req: Request{Method: "GET", Path: "/info", Query: map[string][]string{"group": {"Muse"}, "song": {"Uprising"}, "page": {"1"}}}},
+		{name: "other value", req: Request{Method: "GET", Path: "/info", Query: map[string][]string{"group": {"Queen"}, "song": {"Uprising"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interaction, found := r.find(tt.req)
+			if found != tt.found {
+				t.Fatalf("found = %v, want %v", found, tt.found)
+			}
+			if interaction.Response.Status != tt.status {
+				t.Errorf("status = %d, want %d", interaction.Response.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestRecorderMatchQueryOrder(t *testing.T) {
+	r := &Recorder{played: make(map[int]int)}
+	recorded := Request{Method: "GET", Path: "/info", Query: map[string][]string{"tag": {"rock", "alt"}, "group": {"Muse"}}}
+	u, err := url.Parse("http://musicinfo.test/info?tag=alt&group=Muse&tag=rock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.match(recorded, r.toRequest(&http.Request{Method: "GET", URL: u})) {
+		t.Error("request with params in other order doesn't match")
+	}
+}
+
+func TestResponseContentLength(t *testing.T) {
+	resp, err := Response{
+		Status:  200,
+		Headers: map[string][]string{"Content-Length": {"308"}},
+		Body:    `{"text":"edited"}`,
+	}.toHTTP(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Content-Length"); got != "17" || resp.ContentLength != 17 {
+		t.Errorf("Content-Length = %s, ContentLength = %d, want 17", got, resp.ContentLength)
+	}
+}
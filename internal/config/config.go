@@ -1,38 +1,195 @@
+// Configuration of the service. Values are layered, later layers override earlier:
+// defaults (envDefault tags), config file (YAML or TOML), .env file, environment, flags
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v10"
 	"github.com/joho/godotenv"
 
+	"github.com/Rolan335/Musiclib/internal/accesslog"
+	"github.com/Rolan335/Musiclib/internal/auth"
+	"github.com/Rolan335/Musiclib/internal/cassette"
+	"github.com/Rolan335/Musiclib/internal/health"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/logger/redact"
+	"github.com/Rolan335/Musiclib/internal/metrics"
+	"github.com/Rolan335/Musiclib/internal/ratelimit"
 	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/repository/upstream"
+	"github.com/Rolan335/Musiclib/internal/resync"
+	"github.com/Rolan335/Musiclib/internal/tracing"
+	"github.com/Rolan335/Musiclib/internal/validation"
 )
 
+// suffix of variables with path to file containing the value, e.g. POSTGRES_PASSWORD_FILE
+const fileSuffix = "_FILE"
+
 type ExternalApiConfig struct {
-	URL string `env:"EXTERNAL_API_URL"`
+	URL        string `env:"EXTERNAL_API_URL"`
+	Cassette   cassette.Config
+	Validation upstream.Config
 }
 
 type Config struct {
-	Port           string        `env:"PORT"`
-	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT"`
-	LogLevel       string        `env:"LOG_LEVEL"`
-	GinMode        string        `env:"GIN_MODE"`
+	Port           string        `env:"PORT" envDefault:":8080"`
+	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"5s"`
+	Log            logger.Config
+	LogRedact      redact.Policy
+	GinMode        string `env:"GIN_MODE" envDefault:"release"`
 	DB             postgres.Config
 	API            ExternalApiConfig
 	Migration      postgres.MigrationConfig
+	Resync         resync.Config
+	Auth           auth.Config
+	RateLimit      ratelimit.Config
+	Validation     validation.Config
+	Metrics        metrics.Config
+	Tracing        tracing.Config
+	Health         health.Config
+	AccessLog      accesslog.Config
+}
+
+// Options are sources of configuration besides environment
+type Options struct {
+	// YAML or TOML file, optional
+	File string
+	// dotenv file, missing file is ignored
+	EnvFile string
+	// values from flags, override everything
+	Overrides map[string]string
 }
 
-func MustNewConfig() *Config {
-	//load env file
-	if err := godotenv.Load(".env"); err != nil {
-		panic("failed to load env file: " + err.Error())
+// Load reads configuration from all layers and validates it. All found problems are returned at once
+func Load(opts Options) (*Config, error) {
+	params, err := env.GetFieldParams(&Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config fields: %w", err)
+	}
+	known := make(map[string]bool, len(params))
+	values := make(map[string]string, len(params))
+	for _, p := range params {
+		known[p.Key] = true
+		if p.HasDefaultValue {
+			values[p.Key] = p.DefaultValue
+		}
+	}
+	isKnown := func(key string) bool {
+		return known[key] || known[strings.TrimSuffix(key, fileSuffix)]
 	}
 
-	//parse env file
-	cfg := Config{}
-	if err := env.Parse(&cfg); err != nil {
-		panic("failed to parse env: " + err.Error())
+	var problems []error
+	if opts.File != "" {
+		fileValues, err := readFile(opts.File)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedKeys(fileValues) {
+			value := fileValues[key]
+			if !isKnown(key) {
+				problems = append(problems, fmt.Errorf("%s: unknown key in %s", key, opts.File))
+				continue
+			}
+			values[key] = value
+		}
+	}
+	if opts.EnvFile != "" {
+		dotenv, err := godotenv.Read(opts.EnvFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read env file: %w", err)
+		}
+		for key, value := range dotenv {
+			if isKnown(key) {
+				values[key] = value
+			}
+		}
+	}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if isKnown(key) {
+			values[key] = value
+		}
 	}
-	return &cfg
+	for _, key := range sortedKeys(opts.Overrides) {
+		value := opts.Overrides[key]
+		if !isKnown(key) {
+			problems = append(problems, fmt.Errorf("%s: unknown key in flags", key))
+			continue
+		}
+		values[key] = value
+	}
+	problems = append(problems, resolveFiles(values, known)...)
+
+	var cfg Config
+	if err := env.ParseWithOptions(&cfg, env.Options{Environment: values}); err != nil {
+		var aggregate env.AggregateError
+		if errors.As(err, &aggregate) {
+			problems = append(problems, aggregate.Errors...)
+		} else {
+			problems = append(problems, err)
+		}
+	}
+	//migrations use the same connection unless it is set explicitly
+	if cfg.Migration.ConnStr == "" {
+		cfg.Migration.ConnStr = cfg.DB.ConnString()
+	}
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return nil, &Error{Problems: problems}
+	}
+	return &cfg, nil
+}
+
+// resolveFiles reads values of KEY_FILE variables into KEY.
+// Keys of config itself ending with _FILE (LOG_FILE, TRACING_FILE) are not references
+func resolveFiles(values map[string]string, known map[string]bool) []error {
+	var problems []error
+	for _, key := range sortedKeys(values) {
+		path := values[key]
+		if !strings.HasSuffix(key, fileSuffix) || known[key] || path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		values[strings.TrimSuffix(key, fileSuffix)] = strings.TrimRight(string(data), "\r\n")
+	}
+	return problems
+}
+
+// sortedKeys returns keys of m in order, so problems are reported in the same order every time
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Error lists all problems of configuration
+type Error struct {
+	Problems []error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid config:")
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.Error())
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() []error {
+	return e.Problems
 }
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemp writes file into temporary dir of the test and returns its path
+func writeTemp(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// required sets variables without defaults, so only checked values fail validation
+func required(t *testing.T) {
+	t.Setenv("POSTGRES_USER", "musiclib")
+	t.Setenv("EXTERNAL_API_URL", "http://musicinfo.test")
+}
+
+func TestLoadLayers(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		envFile  string
+		env      string
+		override string
+		want     string
+	}{
+		{name: "default", want: ":8080"},
+		{name: "file", file: ":9001", want: ":9001"},
+		{name: "env file over file", file: ":9001", envFile: ":9002", want: ":9002"},
+		{name: "environment over env file", file: ":9001", envFile: ":9002", env: ":9003", want: ":9003"},
+		{name: "flags over everything", file: ":9001", envFile: ":9002", env: ":9003", override: ":9004", want: ":9004"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			required(t)
+			var opts Options
+			if tt.file != "" {
+				opts.File = writeTemp(t, "config.yaml", "port: \""+tt.file+"\"\n")
+			}
+			if tt.envFile != "" {
+				opts.EnvFile = writeTemp(t, ".env", "PORT="+tt.envFile+"\n")
+			} else {
+				//missing env file is ignored
+				opts.EnvFile = filepath.Join(t.TempDir(), ".env")
+			}
+			if tt.env != "" {
+				t.Setenv("PORT", tt.env)
+			} else {
+				t.Setenv("PORT", "")
+				os.Unsetenv("PORT")
+			}
+			if tt.override != "" {
+				opts.Overrides = map[string]string{"PORT": tt.override}
+			}
+
+			cfg, err := Load(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Port != tt.want {
+				t.Errorf("Port = %q, want %q", cfg.Port, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	required(t)
+	path := writeTemp(t, "config.yaml", `
+postgres:
+  host: db
+  max-conns: 20
+auth:
+  policy:
+    GetProposals: viewer
+log:
+  package_levels:
+    postgres: debug
+`)
+	cfg, err := Load(Options{File: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DB.Host != "db" || cfg.DB.MaxConns != 20 {
+		t.Errorf("DB = %+v, want host db and 20 conns", cfg.DB)
+	}
+	if cfg.Auth.Policy["GetProposals"] != "viewer" {
+		t.Errorf("Auth.Policy = %v", cfg.Auth.Policy)
+	}
+	if cfg.Log.PackageLevels["postgres"] != "debug" {
+		t.Errorf("Log.PackageLevels = %v", cfg.Log.PackageLevels)
+	}
+	//migrations use the same connection
+	if !strings.Contains(cfg.Migration.ConnStr, "db") {
+		t.Errorf("Migration.ConnStr = %q", cfg.Migration.ConnStr)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	required(t)
+	t.Setenv("POSTGRES_PASSWORD_FILE", writeTemp(t, "password", "s3cret\n"))
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DB.Password != "s3cret" {
+		t.Errorf("Password = %q, want value from file without newline", cfg.DB.Password)
+	}
+
+	t.Setenv("POSTGRES_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	_, err = Load(Options{})
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) || !strings.Contains(err.Error(), "POSTGRES_PASSWORD_FILE") {
+		t.Errorf("err = %v, want problem of POSTGRES_PASSWORD_FILE", err)
+	}
+}
+
+func TestLoadProblems(t *testing.T) {
+	required(t)
+	path := writeTemp(t, "config.toml", `
+zeta_unknown = 1
+alpha_unknown = 2
+port = "8080"
+
+[rate_limit]
+read_rps = -1
+`)
+	opts := Options{
+		File:      path,
+		Overrides: map[string]string{"Z_FLAG": "1", "A_FLAG": "1", "AUTH_POLICY": "PostSongs=root,GetSongs=owner"},
+	}
+	want := []string{
+		"ALPHA_UNKNOWN: unknown key in " + path,
+		"ZETA_UNKNOWN: unknown key in " + path,
+		"A_FLAG: unknown key in flags",
+		"Z_FLAG: unknown key in flags",
+		`PORT: "8080" is not a listen address like ":8080"`,
+		"AUTH_POLICY: operation GetSongs",
+		"AUTH_POLICY: operation PostSongs",
+		"RATE_LIMIT_READ_RPS: is negative",
+	}
+	//all problems are reported at once and in the same order every time
+	for i := 0; i < 5; i++ {
+		_, err := Load(opts)
+		var cfgErr *Error
+		if !errors.As(err, &cfgErr) {
+			t.Fatalf("err = %v, want *Error", err)
+		}
+		if len(cfgErr.Problems) != len(want) {
+			t.Fatalf("problems:\n%v\nwant %d", err, len(want))
+		}
+		for j, problem := range cfgErr.Problems {
+			if !strings.HasPrefix(problem.Error(), want[j]) {
+				t.Errorf("problem %d = %q, want prefix %q", j, problem, want[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads YAML or TOML config. Nested keys are joined with "_" and uppercased to names
+// of environment variables, so "postgres: {host: db}" sets POSTGRES_HOST. Lists are joined with ",",
+// maps of scalars under known variables (e.g. auth.policy) become "key=value" pairs
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	values := make(map[string]string)
+	flatten("", raw, values)
+	return values, nil
+}
+
+func flatten(prefix string, raw map[string]interface{}, values map[string]string) {
+	for key, value := range raw {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if pairs, ok := keyValues(v); ok && mapVariables[name] {
+				values[name] = pairs
+				continue
+			}
+			flatten(name, v, values)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+}
+
+// mapVariables are variables parsed into maps, their values in file are maps too
+var mapVariables = map[string]bool{
+	"AUTH_POLICY":        true,
+	"UPSTREAM_RULES":     true,
+	"LOG_PACKAGE_LEVELS": true,
+}
+
+// keyValues formats map of scalars as "a=1,b=2"
+func keyValues(m map[string]interface{}) (string, bool) {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return "", false
+		}
+		pairs = append(pairs, k+"="+fmt.Sprint(v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ","), true
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Rolan335/Musiclib/internal/logger/redact"
+)
+
+const (
+	PrintEnv  = "env"
+	PrintYAML = "yaml"
+)
+
+// parts of names of variables with secrets
+var secretParts = []string{"PASSWORD", "SECRET", "TOKEN", "BOOTSTRAP_KEY"}
+
+// password in connection strings, key=value and url forms
+var passwordRe = regexp.MustCompile(`(password=)[^\s&]+|(://[^:/@]+:)[^@]+(@)`)
+
+// Print writes effective values of all variables with secrets redacted. Values are taken from parsed config,
+// so defaults, _FILE references and derived values (GOOSE_DBSTRING) are shown as the service uses them.
+// Output can be used as .env file (env) or config file (yaml)
+func (c *Config) Print(w io.Writer, format string) error {
+	values := make(map[string]string)
+	collect(reflect.ValueOf(*c), "", values)
+	keys := sortedKeys(values)
+	redacted := make(map[string]string, len(keys))
+	for _, key := range keys {
+		redacted[key] = redactValue(key, values[key])
+	}
+	switch format {
+	case PrintEnv, "":
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(w, "%s=%q\n", key, redacted[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case PrintYAML:
+		return yaml.NewEncoder(w).Encode(redacted)
+	default:
+		return fmt.Errorf("unknown format %q, expected env or yaml", format)
+	}
+}
+
+// collect formats fields with env tag back to variables, nested structs are walked with their envPrefix
+func collect(v reflect.Value, prefix string, values map[string]string) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				collect(v.Field(i), prefix+field.Tag.Get("envPrefix"), values)
+			}
+			continue
+		}
+		key, _, _ = strings.Cut(key, ",")
+		values[prefix+key] = formatValue(v.Field(i), field.Tag)
+	}
+}
+
+// formatValue formats value the way env parses it: separated slices and key-value maps
+func formatValue(v reflect.Value, tag reflect.StructTag) string {
+	separator := tag.Get("envSeparator")
+	if separator == "" {
+		separator = ","
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i), "")
+		}
+		return strings.Join(parts, separator)
+	case reflect.Map:
+		keyValSeparator := tag.Get("envKeyValSeparator")
+		if keyValSeparator == "" {
+			keyValSeparator = ":"
+		}
+		parts := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			parts = append(parts, formatValue(key, "")+keyValSeparator+formatValue(v.MapIndex(key), ""))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, separator)
+	}
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// redactValue hides secrets completely, passwords in connection strings are replaced too
+func redactValue(key string, value string) string {
+	if value == "" || strings.HasSuffix(key, fileSuffix) {
+		return value
+	}
+	for _, part := range secretParts {
+		if strings.Contains(key, part) {
+			return redact.Masked
+		}
+	}
+	return passwordRe.ReplaceAllString(value, "${1}${2}"+redact.Masked+"${3}")
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Rolan335/Musiclib/internal/cassette"
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/ratelimit"
+	"github.com/Rolan335/Musiclib/internal/resync"
+	"github.com/Rolan335/Musiclib/internal/tracing"
+)
+
+// validate returns all problems of values, not only the first one
+func (c *Config) validate() []error {
+	var problems []error
+	check := func(ok bool, key string, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	_, port, err := net.SplitHostPort(c.Port)
+	check(err == nil && validPort(port), "PORT", "%q is not a listen address like \":8080\"", c.Port)
+	check(c.RequestTimeout > 0, "REQUEST_TIMEOUT", "should be positive")
+	check(oneOf(c.GinMode, "debug", "release", "test"), "GIN_MODE", "%q is not one of debug, release, test", c.GinMode)
+
+	check(c.DB.Host != "", "POSTGRES_HOST", "is empty")
+	check(c.DB.Port > 0 && c.DB.Port < 65536, "POSTGRES_PORT", "%d is out of range", c.DB.Port)
+	check(c.DB.User != "", "POSTGRES_USER", "is empty")
+	check(oneOf(strings.ToLower(c.DB.SSLMode), "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full"),
+		"POSTGRES_SSLMODE", "%q is not one of disable, allow, prefer, require, verify-ca, verify-full", c.DB.SSLMode)
+	for _, file := range []struct{ key, path string }{
+		{"POSTGRES_SSLROOTCERT", c.DB.SSLRootCert},
+		{"POSTGRES_SSLCERT", c.DB.SSLCert},
+		{"POSTGRES_SSLKEY", c.DB.SSLKey},
+	} {
+		_, err := os.Stat(file.path)
+		check(file.path == "" || err == nil, file.key, "%v", err)
+	}
+	check((c.DB.SSLCert == "") == (c.DB.SSLKey == ""), "POSTGRES_SSLKEY", "client certificate and key should be set together")
+	check(c.DB.ConnectTimeout >= 0, "POSTGRES_CONNECT_TIMEOUT", "is negative")
+	check(c.DB.StatementTimeout >= 0, "POSTGRES_STATEMENT_TIMEOUT", "is negative")
+	check(c.DB.MaxConns >= 0, "POSTGRES_MAX_CONNS", "is negative")
+	check(c.DB.MinConns >= 0, "POSTGRES_MIN_CONNS", "is negative")
+	check(c.DB.MaxConns == 0 || c.DB.MinConns <= c.DB.MaxConns, "POSTGRES_MIN_CONNS", "%d is greater than POSTGRES_MAX_CONNS %d", c.DB.MinConns, c.DB.MaxConns)
+	check(c.DB.MaxConnLifetime >= 0, "POSTGRES_MAX_CONN_LIFETIME", "is negative")
+	check(c.DB.MaxConnIdleTime >= 0, "POSTGRES_MAX_CONN_IDLE_TIME", "is negative")
+	check(c.DB.HealthCheckPeriod >= 0, "POSTGRES_HEALTH_CHECK_PERIOD", "is negative")
+	check(c.DB.Replica.Port >= 0 && c.DB.Replica.Port < 65536, "POSTGRES_REPLICA_PORT", "%d is out of range", c.DB.Replica.Port)
+	check(c.DB.Replica.StickyPeriod >= 0, "POSTGRES_REPLICA_STICKY_PERIOD", "is negative")
+	check(c.DB.Startup.Timeout > 0, "POSTGRES_STARTUP_TIMEOUT", "should be positive")
+	check(c.DB.Startup.Backoff > 0, "POSTGRES_STARTUP_BACKOFF", "should be positive")
+	check(c.DB.Startup.MaxBackoff >= c.DB.Startup.Backoff, "POSTGRES_STARTUP_MAX_BACKOFF", "is less than POSTGRES_STARTUP_BACKOFF")
+
+	action := strings.ToLower(c.Migration.Action)
+	check(oneOf(action, "", "up", "down", "no"), "GOOSE_MIGRATE", "%q is not one of up, down, no", c.Migration.Action)
+	if action == "up" || action == "down" {
+		check(c.Migration.Driver != "", "GOOSE_DRIVER", "is empty")
+	}
+	if c.Migration.MigrationsPath != "" {
+		info, err := os.Stat(c.Migration.MigrationsPath)
+		check(err == nil && info.IsDir(), "GOOSE_MIGRATION_DIR", "%q is not a directory", c.Migration.MigrationsPath)
+	}
+
+	apiURL, err := url.Parse(c.API.URL)
+	check(err == nil && apiURL.IsAbs() && apiURL.Host != "", "EXTERNAL_API_URL", "%q is not an absolute url", c.API.URL)
+	cassetteMode := strings.ToLower(c.API.Cassette.Mode)
+	check(oneOf(cassetteMode, "", cassette.ModeOff, cassette.ModeRecord, cassette.ModeReplay, cassette.ModeReplayOrRecord),
+		"CASSETTE_MODE", "%q is unknown", c.API.Cassette.Mode)
+	check(oneOf(cassetteMode, "", cassette.ModeOff) || c.API.Cassette.Path != "", "CASSETTE_PATH", "is empty")
+
+	if c.Resync.Enabled {
+		check(c.Resync.Interval > 0, "RESYNC_INTERVAL", "should be positive")
+		check(c.Resync.BatchSize > 0, "RESYNC_BATCH_SIZE", "should be positive")
+		check(oneOf(strings.ToLower(c.Resync.Mode), resync.ModeApply, resync.ModePropose), "RESYNC_MODE", "%q is not one of apply, propose", c.Resync.Mode)
+	}
+
+	for _, op := range sortedKeys(c.Auth.Policy) {
+		_, err := entity.ParseRole(c.Auth.Policy[op])
+		check(err == nil, "AUTH_POLICY", "operation %s: %v", op, err)
+	}
+
+	check(c.RateLimit.IPRPS >= 0, "RATE_LIMIT_IP_RPS", "is negative")
+	check(c.RateLimit.IPBurst >= 0, "RATE_LIMIT_IP_BURST", "is negative")
+	for _, class := range []struct {
+		prefix string
+		limit  ratelimit.Limit
+	}{
+		{"RATE_LIMIT_READ_", c.RateLimit.Read},
+		{"RATE_LIMIT_WRITE_", c.RateLimit.Write},
+		{"RATE_LIMIT_UPSTREAM_", c.RateLimit.Upstream},
+	} {
+		check(class.limit.RPS >= 0, class.prefix+"RPS", "is negative")
+		check(class.limit.Burst >= 0, class.prefix+"BURST", "is negative")
+		check(class.limit.DailyQuota >= 0, class.prefix+"DAILY_QUOTA", "is negative")
+	}
+
+	_, err = logger.ParseLevel(c.Log.Level)
+	check(err == nil, "LOG_LEVEL", "%v", err)
+	for _, pkg := range sortedKeys(c.Log.PackageLevels) {
+		_, err := logger.ParseLevel(c.Log.PackageLevels[pkg])
+		check(err == nil, "LOG_PACKAGE_LEVELS", "package %s: %v", pkg, err)
+	}
+	check(oneOf(strings.ToLower(c.Log.Format), "", logger.FormatJSON, logger.FormatText), "LOG_FORMAT", "%q is not one of json, text", c.Log.Format)
+
+	exporter := strings.ToLower(c.Tracing.Exporter)
+	check(oneOf(exporter, "", tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterFile), "TRACING_EXPORTER", "%q is unknown", c.Tracing.Exporter)
+	check(exporter != tracing.ExporterFile || c.Tracing.File != "", "TRACING_FILE", "is empty")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "should be from 0 to 1")
+
+	check(c.AccessLog.SampleRate >= 0 && c.AccessLog.SampleRate <= 1, "ACCESS_LOG_SAMPLE_RATE", "should be from 0 to 1")
+	check(c.Validation.MaxPageSize >= 0, "VALIDATION_MAX_PAGE_SIZE", "is negative")
+	check(c.Validation.MaxTextPageSize >= 0, "VALIDATION_MAX_TEXT_PAGE_SIZE", "is negative")
+	check(c.Health.Timeout >= 0, "HEALTH_TIMEOUT", "is negative")
+	return problems
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n < 65536
+}
+
+func oneOf(value string, allowed ...string) bool {
+	return slices.Contains(allowed, value)
+}
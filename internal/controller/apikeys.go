@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+func (s *Server) GetAuthKeys(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	defer cancel()
+	keys, err := s.service.GetAPIKeys(ctx)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (s *Server) PostAuthKeys(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	defer cancel()
+	var body api.PostAuthKeysJSONRequestBody
+	if err := bindJSON(c, &body); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	key, err := s.service.CreateAPIKey(ctx, body.Name, string(body.Role))
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+func (s *Server) DeleteAuthKeysId(c *gin.Context, id int) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	defer cancel()
+	if err := s.service.RevokeAPIKey(ctx, id); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
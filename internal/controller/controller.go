@@ -2,41 +2,45 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
-	"io"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
 	"github.com/Rolan335/Musiclib/internal/musiclib"
 	"github.com/Rolan335/Musiclib/pkg/api"
-	"github.com/Rolan335/Musiclib/pkg/musicinfo"
 )
 
+// Upstream is the external api with details of songs
+type Upstream interface {
+	GetSongDetail(ctx context.Context, group string, title string) (entity.Song, error)
+}
+
 type Server struct {
-	timeout      time.Duration
-	extApiClient *musicinfo.Client
-	service      *musiclib.MusicLib
+	timeout   time.Duration
+	upstream  Upstream
+	service   *musiclib.MusicLib
+	logLevels *logger.Levels
+	writes    *readYourWrites
 }
 
-func MustNewServer(service *musiclib.MusicLib, externalApiURL string, timeout time.Duration) *Server {
-	//Creating client for external api
-	client, err := musicinfo.NewClient(externalApiURL)
-	if err != nil {
-		panic("can't create client: " + err.Error())
-	}
+// NewServer creates handlers. Reads of songs go to primary for stickyPeriod after caller's own writes, 0 disables it
+func NewServer(service *musiclib.MusicLib, upstream Upstream, logLevels *logger.Levels, timeout time.Duration, stickyPeriod time.Duration) *Server {
 	return &Server{
-		extApiClient: client,
-		service:      service,
-		timeout:      timeout,
+		upstream:  upstream,
+		service:   service,
+		logLevels: logLevels,
+		timeout:   timeout,
+		writes:    newReadYourWrites(stickyPeriod),
 	}
 }
 
 func (s *Server) GetSongs(c *gin.Context, params api.GetSongsParams) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	ctx, cancel := context.WithTimeout(s.writes.context(c), s.timeout)
 	defer cancel()
 	//Parsing date params
 	var dateFrom, dateTo *time.Time
@@ -56,7 +60,7 @@ func (s *Server) GetSongs(c *gin.Context, params api.GetSongsParams) {
 		PageSize: params.PageSize,
 	})
 	if err != nil {
-		c.JSON(500, gin.H{"error": ErrInternalServer.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(200, songs)
@@ -66,42 +70,27 @@ func (s *Server) PostSongs(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
 	defer cancel()
 	var song Song
-	if err := c.BindJSON(&song); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": ErrFailedToParse.Error()})
+	if err := bindJSON(c, &song); err != nil {
+		abortWithError(c, err)
 		return
 	}
-
-	resp, err := s.extApiClient.GetInfo(ctx, &musicinfo.GetInfoParams{Song: song.Title, Group: song.Group})
-	if err != nil {
-		c.JSON(http.StatusGatewayTimeout, gin.H{"error": ErrGatewayTimeout.Error()})
+	//spec validation can be disabled, empty song isn't worth a request to external api
+	if strings.TrimSpace(song.Group) == "" || strings.TrimSpace(song.Title) == "" {
+		abortWithError(c, fmt.Errorf("group and title are required: %w", musiclib.ErrInvalidParams))
 		return
 	}
-	defer resp.Body.Close()
-	//If not found in external api, return not found
-	if resp.StatusCode != http.StatusOK {
-		c.JSON(http.StatusNotFound, gin.H{"error": ErrNotFound.Error()})
-		return
-	}
-	body, err := io.ReadAll(resp.Body)
+
+	detail, err := s.upstream.GetSongDetail(ctx, song.Group, song.Title)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrInternalServer.Error()})
+		abortWithError(c, err)
 		return
 	}
-	if err := json.Unmarshal(body, &song); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrFailedToParse.Error()})
-		return
-	}
-	id, err := s.service.CreateSong(ctx, entity.Song{
-		Group:       song.Group,
-		Title:       song.Title,
-		ReleaseDate: song.ReleaseDate.Time(),
-		Text:        song.Text,
-		Link:        song.Link,
-	})
+	id, err := s.service.CreateSong(ctx, detail)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrInternalServer.Error()})
+		abortWithError(c, err)
 		return
 	}
+	s.writes.wrote(c)
 	c.JSON(http.StatusCreated, gin.H{"id": id})
 }
 
@@ -109,13 +98,10 @@ func (s *Server) DeleteSongsId(c *gin.Context, id int) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
 	defer cancel()
 	if err := s.service.DeleteSong(ctx, id); err != nil {
-		if errors.Is(err, musiclib.ErrSongNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": ErrNotFound.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrInternalServer.Error()})
+		abortWithError(c, err)
 		return
 	}
+	s.writes.wrote(c)
 	c.JSON(http.StatusOK, gin.H{})
 }
 
@@ -123,8 +109,8 @@ func (s *Server) PatchSongsId(c *gin.Context, id int) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
 	defer cancel()
 	var song SongNullable
-	if err := c.BindJSON(&song); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": ErrFailedToParse.Error()})
+	if err := bindJSON(c, &song); err != nil {
+		abortWithError(c, err)
 		return
 	}
 	//Check for nil time
@@ -140,20 +126,29 @@ func (s *Server) PatchSongsId(c *gin.Context, id int) {
 		ReleaseDate: releaseDate,
 		Text:        song.Text,
 		Link:        song.Link,
+		Locked:      song.Locked,
 	})
 	if err != nil {
-		if errors.Is(err, musiclib.ErrSongNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": ErrNotFound.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrInternalServer.Error()})
+		abortWithError(c, err)
 		return
 	}
+	s.writes.wrote(c)
 	c.JSON(http.StatusOK, gin.H{})
 }
 
+func (s *Server) GetSongsId(c *gin.Context, id int) {
+	ctx, cancel := context.WithTimeout(s.writes.context(c), s.timeout)
+	defer cancel()
+	song, err := s.service.GetSong(ctx, id)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, song)
+}
+
 func (s *Server) GetSongsIdText(c *gin.Context, id int, params api.GetSongsIdTextParams) {
-	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	ctx, cancel := context.WithTimeout(s.writes.context(c), s.timeout)
 	defer cancel()
 	//default values if nil
 	page := 1
@@ -164,17 +159,13 @@ func (s *Server) GetSongsIdText(c *gin.Context, id int, params api.GetSongsIdTex
 	if params.PageSize != nil {
 		pageSize = *params.PageSize
 	}
-	text, err := s.service.GetSongText(ctx, id, page, pageSize)
+	section := ""
+	if params.Section != nil {
+		section = string(*params.Section)
+	}
+	text, err := s.service.GetSongText(ctx, id, page, pageSize, section)
 	if err != nil {
-		if errors.Is(err, musiclib.ErrSongNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": ErrNotFound.Error()})
-			return
-		}
-		if errors.Is(err, musiclib.ErrInvalidParams) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": ErrBadRequest.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": ErrInternalServer.Error()})
+		abortWithError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, text)
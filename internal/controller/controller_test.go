@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/cassette"
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/musiclib"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/repository/upstream"
+	"github.com/Rolan335/Musiclib/pkg/api"
+	"github.com/Rolan335/Musiclib/pkg/musicinfo"
+)
+
+// storage keeps created songs, other methods aren't used by tests
+type storage struct {
+	musiclib.Storage
+	created []entity.Song
+}
+
+// GetSong returns created song by its position
+func (s *storage) GetSong(_ context.Context, id int) (entity.Song, error) {
+	if id < 1 || id > len(s.created) {
+		return entity.Song{}, postgres.ErrNotFound
+	}
+	return s.created[id-1], nil
+}
+
+func (s *storage) CreateSong(_ context.Context, song entity.Song) (int, error) {
+	s.created = append(s.created, song)
+	return len(s.created), nil
+}
+
+func newTestServer(t *testing.T) (*Server, *storage) {
+	t.Helper()
+	rec, err := cassette.New(cassette.Config{Mode: cassette.ModeReplay, Path: "../../testdata/cassettes/musicinfo.json"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := musicinfo.NewClientWithResponses("http://musicinfo.test", musicinfo.WithHTTPClient(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, err := upstream.New(client, upstream.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := &storage{}
+	l := logger.New("error", io.Discard)
+	return NewServer(musiclib.NewMusicLib(db, l), up, nil, time.Second, 0), db
+}
+
+// TestPostSongs replays quirks of external api recorded in testdata/cassettes/musicinfo.json
+func TestPostSongs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		group  string
+		title  string
+		status int
+		code   string
+		song   *entity.Song
+	}{
+		{
+			name:   "valid",
+			group:  "Muse",
+			title:  "Supermassive Black Hole",
+			status: http.StatusCreated,
+			song: &entity.Song{
+				Group:       "Muse",
+				Title:       "Supermassive Black Hole",
+				ReleaseDate: time.Date(2006, time.July, 16, 0, 0, 0, 0, time.UTC),
+				Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+			},
+		},
+		{name: "missing release date", group: "Quirks", title: "Missing release date", status: http.StatusBadGateway, code: "upstream_contract_violation"},
+		{name: "empty text", group: "Quirks", title: "Empty text", status: http.StatusBadGateway, code: "upstream_contract_violation"},
+		{name: "iso release date", group: "Quirks", title: "ISO release date", status: http.StatusBadGateway, code: "upstream_contract_violation"},
+		{
+			name:   "overwrites group",
+			group:  "Quirks",
+			title:  "Overwrites group",
+			status: http.StatusCreated,
+			song: &entity.Song{
+				Group:       "Quirks",
+				Title:       "Overwrites group",
+				ReleaseDate: time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC),
+				Text:        "Verse",
+				Link:        "https://example.com/overwrites-group",
+			},
+		},
+		{name: "internal error", group: "Quirks", title: "Internal error", status: http.StatusBadGateway, code: "upstream_unavailable"},
+		{name: "not found", group: "Unknown", title: "Song", status: http.StatusNotFound, code: "upstream_song_not_found"},
+		{name: "empty title", group: "Muse", title: " ", status: http.StatusBadRequest, code: "validation_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db := newTestServer(t)
+			body, _ := json.Marshal(map[string]string{"group": tt.group, "title": tt.title})
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/songs", bytes.NewReader(body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			s.PostSongs(c)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.code != "" {
+				var p struct {
+					Code string `json:"code"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+					t.Fatal(err)
+				}
+				if p.Code != tt.code {
+					t.Errorf("code = %q, want %q", p.Code, tt.code)
+				}
+			}
+			if tt.song == nil {
+				if len(db.created) != 0 {
+					t.Errorf("song was created: %+v", db.created)
+				}
+				return
+			}
+			if len(db.created) != 1 {
+				t.Fatalf("created %d songs, want 1", len(db.created))
+			}
+			got := db.created[0]
+			if got.Group != tt.song.Group || got.Title != tt.song.Title || !got.ReleaseDate.Equal(tt.song.ReleaseDate) || got.Link != tt.song.Link {
+				t.Errorf("created %+v, want %+v", got, *tt.song)
+			}
+			if tt.song.Text != "" && got.Text != tt.song.Text {
+				t.Errorf("text = %q, want %q", got.Text, tt.song.Text)
+			}
+		})
+	}
+}
+
+func TestGetSongsIdText(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, db := newTestServer(t)
+	db.created = append(db.created, entity.Song{Text: "[Verse]\nwalking\n\n[Chorus]\nsinging"})
+	tests := []struct {
+		name    string
+		section api.SectionType
+		want    string
+	}{
+		{name: "all sections", want: `{"text":[["walking"]],"sections":[{"type":"verse","label":"Verse","lines":["walking"],"repeat":1}]}`},
+		{name: "filtered", section: api.Chorus, want: `{"text":[["singing"]],"sections":[{"type":"chorus","label":"Chorus","lines":["singing"],"repeat":1}]}`},
+		{name: "no sections of type", section: api.Bridge, want: `{"text":[],"sections":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/songs/1/text", nil)
+			var params api.GetSongsIdTextParams
+			if tt.section != "" {
+				params.Section = &tt.section
+			}
+
+			s.GetSongsIdText(c, 1, params)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
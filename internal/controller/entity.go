@@ -42,4 +42,5 @@ type SongNullable struct {
 	ReleaseDate *CustomTime `json:"releaseDate,omitempty"`
 	Text        *string     `json:"text,omitempty"`
 	Link        *string     `json:"link,omitempty"`
+	Locked      *bool       `json:"locked,omitempty"`
 }
@@ -1,10 +1,72 @@
 package controller
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/musiclib"
+	"github.com/Rolan335/Musiclib/internal/problem"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/repository/upstream"
+)
 
-var ErrBadRequest = errors.New("bad request")
-var ErrGatewayTimeout = errors.New("gateway timeout")
-var ErrBadGateway = errors.New("bad gateway")
-var ErrNotFound = errors.New("not found")
-var ErrInternalServer = errors.New("internal server error")
 var ErrFailedToParse = errors.New("failed to parse body")
+
+// abortWithError writes error of the services as problem details
+func abortWithError(c *gin.Context, err error) {
+	problem.Abort(c, toProblem(err))
+}
+
+// toProblem maps sentinel errors of musiclib, postgres and upstream to problem details.
+// Detail of server errors isn't exposed
+func toProblem(err error) problem.Problem {
+	var contractErr *upstream.ContractError
+	switch {
+	case errors.Is(err, ErrFailedToParse):
+		return problem.New(http.StatusBadRequest, problem.CodeInvalidBody, err.Error())
+	case errors.Is(err, musiclib.ErrInvalidParams), errors.Is(err, logger.ErrInvalidLevel):
+		return problem.New(http.StatusBadRequest, problem.CodeValidationFailed, err.Error())
+	case errors.Is(err, musiclib.ErrSongNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeSongNotFound, err.Error())
+	case errors.Is(err, musiclib.ErrProposalNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeProposalNotFound, err.Error())
+	case errors.Is(err, musiclib.ErrAPIKeyNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeAPIKeyNotFound, err.Error())
+	case errors.Is(err, musiclib.ErrSongLocked):
+		return problem.New(http.StatusConflict, problem.CodeSongLocked, err.Error())
+	case errors.Is(err, postgres.ErrNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, err.Error())
+	case errors.Is(err, upstream.ErrNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeUpstreamSongNotFound, err.Error())
+	case errors.As(err, &contractErr):
+		p := problem.New(http.StatusBadGateway, problem.CodeUpstreamContractViolation, "external api response violates its contract")
+		for _, v := range contractErr.Violations {
+			p = p.WithErrors(problem.FieldError{Field: v.Field, Reason: v.Reason})
+		}
+		return p
+	case errors.Is(err, context.DeadlineExceeded):
+		return problem.New(http.StatusGatewayTimeout, problem.CodeTimeout, "request timed out")
+	case errors.Is(err, upstream.ErrUnavailable):
+		return problem.New(http.StatusBadGateway, problem.CodeUpstreamUnavailable, "external api is unavailable")
+	default:
+		return problem.New(http.StatusInternalServerError, problem.CodeInternal, "")
+	}
+}
+
+// ParamErrorHandler handles errors of parameters binding in generated api wrapper
+func ParamErrorHandler(c *gin.Context, err error, status int) {
+	problem.Abort(c, problem.New(status, problem.CodeInvalidParameter, err.Error()))
+}
+
+// bindJSON decodes body into obj, wrapping errors with ErrFailedToParse
+func bindJSON(c *gin.Context, obj interface{}) error {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToParse, err)
+	}
+	return nil
+}
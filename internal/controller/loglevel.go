@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+func (s *Server) GetAdminLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, s.logLevel())
+}
+
+func (s *Server) PutAdminLogLevel(c *gin.Context) {
+	var body api.PutAdminLogLevelJSONRequestBody
+	if err := bindJSON(c, &body); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	packages := map[string]string{}
+	if body.Packages != nil {
+		for pkg, level := range *body.Packages {
+			packages[pkg] = string(level)
+		}
+	}
+	if err := s.logLevels.SetFromStrings(string(body.Level), packages); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, s.logLevel())
+}
+
+func (s *Server) logLevel() api.LogLevel {
+	base, overrides := s.logLevels.Get()
+	packages := make(map[string]api.Level, len(overrides))
+	for pkg, level := range overrides {
+		packages[pkg] = levelName(level)
+	}
+	return api.LogLevel{Level: levelName(base), Packages: &packages}
+}
+
+func levelName(level slog.Level) api.Level {
+	return api.Level(strings.ToLower(level.String()))
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/auth"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+)
+
+// readYourWrites sends reads of the caller to primary for period after its own writes of songs,
+// so caller sees its changes before they reach replica. Other callers keep reading replica
+type readYourWrites struct {
+	period time.Duration
+
+	mu        sync.Mutex
+	writes    map[string]time.Time
+	lastSweep time.Time
+}
+
+func newReadYourWrites(period time.Duration) *readYourWrites {
+	return &readYourWrites{
+		period: period,
+		writes: make(map[string]time.Time),
+	}
+}
+
+// wrote marks successful write of songs by the caller
+func (r *readYourWrites) wrote(c *gin.Context) {
+	if r.period <= 0 {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Sub(r.lastSweep) > r.period {
+		for caller, at := range r.writes {
+			if now.Sub(at) > r.period {
+				delete(r.writes, caller)
+			}
+		}
+		r.lastSweep = now
+	}
+	r.writes[auth.CallerKey(c)] = now
+}
+
+// context returns request context, reading from primary if the caller wrote recently
+func (r *readYourWrites) context(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if r.period <= 0 {
+		return ctx
+	}
+	r.mu.Lock()
+	at, ok := r.writes[auth.CallerKey(c)]
+	r.mu.Unlock()
+	if ok && time.Since(at) < r.period {
+		return postgres.WithPrimary(ctx)
+	}
+	return ctx
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) GetProposals(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	defer cancel()
+	proposals, err := s.service.GetProposals(ctx)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, proposals)
+}
+
+func (s *Server) PostProposalsIdApply(c *gin.Context, id int) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	defer cancel()
+	if err := s.service.ApplyProposal(ctx, id); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	s.writes.wrote(c)
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (s *Server) DeleteProposalsId(c *gin.Context, id int) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	defer cancel()
+	if err := s.service.RejectProposal(ctx, id); err != nil {
+		abortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
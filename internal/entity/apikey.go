@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// Role of api client, stored in APIKey.Role and jwt claims
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+// rank of the role, role includes permissions of roles with lower rank
+var ranks = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := ranks[role]; !ok {
+		return "", fmt.Errorf("unknown role %q: %w", s, ErrInvalidRole)
+	}
+	return role, nil
+}
+
+// Allows reports whether role has permissions of required role
+func (r Role) Allows(required Role) bool {
+	rank, ok := ranks[r]
+	return ok && rank >= ranks[required]
+}
+
+// prefix of generated keys, makes them recognizable in configs and leaks
+const keyPrefix = "mlk_"
+
+// GenerateKey returns new random api key and its hash for storing
+func GenerateKey() (key string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, HashKey(key), nil
+}
+
+// HashKey returns hex encoded sha256 of the key. Keys have enough entropy, so salt isn't needed
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
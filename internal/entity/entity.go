@@ -23,6 +23,9 @@ type Song struct {
 	ReleaseDate time.Time `json:"releaseDate,omitempty"`
 	Text        string    `json:"text,omitempty"`
 	Link        string    `json:"link,omitempty"`
+	Locked      bool      `json:"locked"`
+	// Text parsed into sections, nil if not parsed yet
+	Lyrics []Section `json:"-"`
 }
 
 type SongNullable struct {
@@ -32,10 +35,58 @@ type SongNullable struct {
 	ReleaseDate *time.Time `json:"releaseDate,omitempty"`
 	Text        *string    `json:"text,omitempty"`
 	Link        *string    `json:"link,omitempty"`
+	Locked      *bool      `json:"locked,omitempty"`
+	// sections of Text, stored with it. nil is parsed later on read
+	Lyrics []Section `json:"-"`
+}
+
+// Proposal represents changes of the song found in external api, waiting for review
+// nil fields are not changed
+type Proposal struct {
+	ID          int        `json:"id"`
+	SongID      int        `json:"songId"`
+	ReleaseDate *time.Time `json:"releaseDate,omitempty"`
+	Text        *string    `json:"text,omitempty"`
+	Link        *string    `json:"link,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
 }
 
 // Text represents text of the song
 // Return text like slice (verse) of slice (string) of strings
 type Text struct {
-	Text [][]string `json:"text,omitempty"`
+	Text     [][]string `json:"text"`
+	Sections []Section  `json:"sections"`
+}
+
+type SectionType string
+
+const (
+	SectionIntro     SectionType = "intro"
+	SectionVerse     SectionType = "verse"
+	SectionPreChorus SectionType = "pre-chorus"
+	SectionChorus    SectionType = "chorus"
+	SectionBridge    SectionType = "bridge"
+	SectionOutro     SectionType = "outro"
+)
+
+// Section is a block of lyrics separated by empty line
+type Section struct {
+	Type SectionType `json:"type"`
+	// header from markup like [Chorus 2], empty if there was none
+	Label string   `json:"label,omitempty"`
+	Lines []string `json:"lines"`
+	// times the section is sung in a row, from markup like [Chorus x2]
+	Repeat int `json:"repeat"`
+}
+
+// APIKey represents static key of api client. Only hash of the key is stored,
+// Key is filled once on creation
+type APIKey struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Key       string     `json:"key,omitempty"`
+	Hash      string     `json:"-"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
 }
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"log/slog"
+
+	"github.com/Rolan335/Musiclib/internal/logger/redact"
+)
+
+// LogValue truncates text of the song, full lyrics are too bulky for logs
+func (s Song) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("id", s.ID),
+		slog.String("group", s.Group),
+		slog.String("title", s.Title),
+		slog.Time("releaseDate", s.ReleaseDate),
+		slog.String("text", redact.Truncate(s.Text)),
+		slog.String("link", s.Link),
+		slog.Bool("locked", s.Locked),
+	)
+}
+
+// LogValue logs only provided fields, text is truncated
+func (s SongNullable) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, 7)
+	if s.ID != nil {
+		attrs = append(attrs, slog.Int("id", *s.ID))
+	}
+	if s.Group != nil {
+		attrs = append(attrs, slog.String("group", *s.Group))
+	}
+	if s.Title != nil {
+		attrs = append(attrs, slog.String("title", *s.Title))
+	}
+	if s.ReleaseDate != nil {
+		attrs = append(attrs, slog.Time("releaseDate", *s.ReleaseDate))
+	}
+	if s.Text != nil {
+		attrs = append(attrs, slog.String("text", redact.Truncate(*s.Text)))
+	}
+	if s.Link != nil {
+		attrs = append(attrs, slog.String("link", *s.Link))
+	}
+	if s.Locked != nil {
+		attrs = append(attrs, slog.Bool("locked", *s.Locked))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func (p Proposal) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("id", p.ID),
+		slog.Int("songId", p.SongID),
+		slog.Time("createdAt", p.CreatedAt),
+	}
+	if p.ReleaseDate != nil {
+		attrs = append(attrs, slog.Time("releaseDate", *p.ReleaseDate))
+	}
+	if p.Text != nil {
+		attrs = append(attrs, slog.String("text", redact.Truncate(*p.Text)))
+	}
+	if p.Link != nil {
+		attrs = append(attrs, slog.String("link", *p.Link))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue logs only count of verses, lines and sections
+func (t Text) LogValue() slog.Value {
+	lines := 0
+	for _, verse := range t.Text {
+		lines += len(verse)
+	}
+	return slog.GroupValue(
+		slog.Int("verses", len(t.Text)),
+		slog.Int("lines", lines),
+		slog.Int("sections", len(t.Sections)),
+	)
+}
+
+// LogValue masks plain key and omits its hash
+func (k APIKey) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("id", k.ID),
+		slog.String("name", k.Name),
+		slog.String("role", k.Role),
+		slog.Time("createdAt", k.CreatedAt),
+	}
+	if k.Key != "" {
+		attrs = append(attrs, slog.String("key", redact.Mask(k.Key)))
+	}
+	if k.RevokedAt != nil {
+		attrs = append(attrs, slog.Time("revokedAt", *k.RevokedAt))
+	}
+	return slog.GroupValue(attrs...)
+}
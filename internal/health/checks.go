@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type MigrationStorage interface {
+	MigrationVersion(ctx context.Context) (int64, error)
+}
+
+// Postgres checks connection to database
+func Postgres(db Pinger) Check {
+	return db.Ping
+}
+
+// Migrations checks that database has migrations up to expected version applied
+func Migrations(db MigrationStorage, expected int64) Check {
+	return func(ctx context.Context) error {
+		version, err := db.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version < expected {
+			return fmt.Errorf("database is at migration %d, expected %d", version, expected)
+		}
+		return nil
+	}
+}
+
+// Upstream checks that external api responds. Any response except 5xx means api is reachable
+func Upstream(url string, client *http.Client) Check {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("external api is unreachable: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("external api responded with status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+// Liveness and readiness endpoints with status of components
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+	// readiness during graceful stop
+	StatusShuttingDown = "shutting_down"
+)
+
+type Config struct {
+	// external api reachability is part of readiness
+	CheckUpstream bool          `env:"HEALTH_CHECK_UPSTREAM"`
+	Timeout       time.Duration `env:"HEALTH_TIMEOUT" envDefault:"2s"`
+	// time between readiness failing and server stop, lets load balancer remove instance
+	ShutdownDelay time.Duration `env:"HEALTH_SHUTDOWN_DELAY" envDefault:"5s"`
+}
+
+// Check returns error if component isn't ready
+type Check func(ctx context.Context) error
+
+type component struct {
+	name  string
+	check Check
+}
+
+type ComponentStatus struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+type Health struct {
+	cfg        Config
+	log        *logger.Log
+	components []component
+	stopping   atomic.Bool
+}
+
+func New(cfg Config, l *logger.Log) *Health {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	return &Health{
+		cfg: cfg,
+		log: l,
+	}
+}
+
+// Add registers component checked by readiness
+func (h *Health) Add(name string, check Check) {
+	h.components = append(h.components, component{name: name, check: check})
+}
+
+// Register adds /healthz and /readyz to router
+func (h *Health) Register(r gin.IRoutes) {
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, Report{Status: StatusOK})
+	})
+	r.GET("/readyz", func(c *gin.Context) {
+		report := h.Ready(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+}
+
+// Ready runs checks of all components concurrently
+func (h *Health) Ready(ctx context.Context) Report {
+	if h.stopping.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	report := Report{
+		Status:     StatusOK,
+		Components: make(map[string]ComponentStatus, len(h.components)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, comp := range h.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := comp.check(ctx)
+			status := ComponentStatus{Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				status.Status = StatusFail
+				status.Error = err.Error()
+				h.log.Error("health: component isn't ready", "component", comp.name, "error", err.Error())
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[comp.name] = status
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// Shutdown makes readiness fail and waits ShutdownDelay. Should be called before stopping server
+func (h *Health) Shutdown() {
+	if h.stopping.Swap(true) {
+		return
+	}
+	h.log.Info("health: readiness is failing, shutting down")
+	time.Sleep(h.cfg.ShutdownDelay)
+}
@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var ErrInvalidLevel = errors.New("invalid log level")
+
+// Levels is the level of logger with overrides per package, can be changed at runtime
+type Levels struct {
+	base slog.LevelVar
+	// the lowest of base and package levels, records below it are dropped before formatting
+	min slog.LevelVar
+
+	mu       sync.RWMutex
+	packages map[string]slog.Level
+	// package of the caller per program counter
+	callers sync.Map
+}
+
+func NewLevels(base slog.Level) *Levels {
+	lv := &Levels{packages: map[string]slog.Level{}}
+	lv.Set(base, nil)
+	return lv
+}
+
+// ParseLevel parses debug, info, warn or error case-insensitively
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("%q: %w", s, ErrInvalidLevel)
+	}
+	return level, nil
+}
+
+// Set replaces base level and overrides of packages. Package is the last element of import path, e.g. "postgres"
+func (lv *Levels) Set(base slog.Level, packages map[string]slog.Level) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	lv.base.Set(base)
+	lv.packages = make(map[string]slog.Level, len(packages))
+	lowest := base
+	for pkg, level := range packages {
+		lv.packages[strings.ToLower(pkg)] = level
+		lowest = min(lowest, level)
+	}
+	lv.min.Set(lowest)
+}
+
+// SetFromStrings parses and sets levels, empty base is info. Nothing is changed on error
+func (lv *Levels) SetFromStrings(base string, packages map[string]string) error {
+	if base == "" {
+		base = slog.LevelInfo.String()
+	}
+	baseLevel, err := ParseLevel(base)
+	if err != nil {
+		return err
+	}
+	parsed := make(map[string]slog.Level, len(packages))
+	for pkg, s := range packages {
+		level, err := ParseLevel(s)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+		parsed[pkg] = level
+	}
+	lv.Set(baseLevel, parsed)
+	return nil
+}
+
+// Get returns base level and copy of package overrides
+func (lv *Levels) Get() (slog.Level, map[string]slog.Level) {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+	packages := make(map[string]slog.Level, len(lv.packages))
+	for pkg, level := range lv.packages {
+		packages[pkg] = level
+	}
+	return lv.base.Level(), packages
+}
+
+// level returns level of package which code made the record
+func (lv *Levels) level(pc uintptr) slog.Level {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+	if len(lv.packages) == 0 || pc == 0 {
+		return lv.base.Level()
+	}
+	if level, ok := lv.packages[lv.caller(pc)]; ok {
+		return level
+	}
+	return lv.base.Level()
+}
+
+// caller returns package name of function at pc,
+// e.g. "postgres" for "github.com/Rolan335/Musiclib/internal/repository/postgres.(*Storage).SelectSongs.func1"
+func (lv *Levels) caller(pc uintptr) string {
+	if pkg, ok := lv.callers.Load(pc); ok {
+		return pkg.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	name := frame.Function[strings.LastIndex(frame.Function, "/")+1:]
+	pkg, _, _ := strings.Cut(name, ".")
+	lv.callers.Store(pc, pkg)
+	return pkg
+}
+
+// levelHandler filters records by level of the package of the caller
+type levelHandler struct {
+	slog.Handler
+	levels *Levels
+}
+
+func (h levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.min.Level()
+}
+
+func (h levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.levels.level(r.PC) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{h.Handler.WithAttrs(attrs), h.levels}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{h.Handler.WithGroup(name), h.levels}
+}
@@ -2,64 +2,150 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
+	"os"
+	"reflect"
+	"runtime"
 	"strings"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger/redact"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
 )
 
+type Config struct {
+	Level string `env:"LOG_LEVEL" envDefault:"info"`
+	// overrides per package, e.g. "postgres=debug,auth=warn"
+	PackageLevels map[string]string `env:"LOG_PACKAGE_LEVELS" envKeyValSeparator:"="`
+	Format        string            `env:"LOG_FORMAT" envDefault:"json"`
+	// logs are written to file instead of stdout, file is rotated by size
+	File           string `env:"LOG_FILE"`
+	FileMaxSizeMB  int    `env:"LOG_FILE_MAX_SIZE_MB" envDefault:"100"`
+	FileMaxBackups int    `env:"LOG_FILE_MAX_BACKUPS" envDefault:"5"`
+	FileMaxAgeDays int    `env:"LOG_FILE_MAX_AGE_DAYS" envDefault:"28"`
+}
+
 type Log struct {
 	*slog.Logger
+	levels *Levels
+	closer io.Closer
 }
 
+// New creates json logger. Unknown level falls back to info
 func New(logLevel string, out io.Writer) *Log {
-	logLevel = strings.ToUpper(logLevel)
-	switch logLevel {
-	case "DEBUG":
-		return &Log{slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))}
-	case "INFO":
-		return &Log{slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelInfo}))}
+	level, err := ParseLevel(logLevel)
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	return newLog(NewLevels(level), FormatJSON, out, nil)
+}
+
+// NewWithConfig creates logger writing to stdout or rotated file. Records get attributes from context,
+// see WithAttrs, and are redacted with policy of redact package
+func NewWithConfig(cfg Config) (*Log, error) {
+	levels := NewLevels(slog.LevelInfo)
+	if err := levels.SetFromStrings(cfg.Level, cfg.PackageLevels); err != nil {
+		return nil, err
+	}
+	format := strings.ToLower(cfg.Format)
+	switch format {
+	case FormatJSON, FormatText:
+	case "":
+		format = FormatJSON
 	default:
-		return &Log{slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelInfo}))}
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+	if cfg.File == "" {
+		return newLog(levels, format, os.Stdout, nil), nil
+	}
+	file := &lumberjack.Logger{
+		Filename:   cfg.File,
+		MaxSize:    cfg.FileMaxSizeMB,
+		MaxBackups: cfg.FileMaxBackups,
+		MaxAge:     cfg.FileMaxAgeDays,
+	}
+	return newLog(levels, format, file, file), nil
+}
+
+func newLog(levels *Levels, format string, out io.Writer, closer io.Closer) *Log {
+	//levels are checked by levelHandler
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redact.ReplaceAttr}
+	var handler slog.Handler = slog.NewJSONHandler(out, opts)
+	if format == FormatText {
+		handler = slog.NewTextHandler(out, opts)
+	}
+	return &Log{
+		Logger: slog.New(levelHandler{contextHandler{handler}, levels}),
+		levels: levels,
+		closer: closer,
+	}
+}
+
+// Levels returns levels of logger, they can be changed at runtime
+func (l *Log) Levels() *Levels {
+	return l.levels
+}
+
+// Close closes log file, if logger writes to file
+func (l *Log) Close() {
+	if l.closer != nil {
+		l.closer.Close()
 	}
 }
 
 // Logging errors on lvl error, and if err == nil, log on lvl debug
 func (l *Log) Standart(ctx context.Context, methodName string, params interface{}, result interface{}, err error) {
 	if err != nil {
-		l.LogAttrs(ctx, slog.LevelError, "musiclib: "+methodName,
-			slog.Any("params", params),
+		l.logCaller(ctx, slog.LevelError, "musiclib: "+methodName,
+			slog.Any("params", loggable(params)),
 			slog.String("error", err.Error()),
 		)
 		return
 	}
-	l.LogAttrs(ctx, slog.LevelDebug, "musiclib: "+methodName,
-		slog.Any("params", params),
-		slog.Any("result", result),
+	l.logCaller(ctx, slog.LevelDebug, "musiclib: "+methodName,
+		slog.Any("params", loggable(params)),
+		slog.Any("result", loggable(result)),
 	)
 }
 
 // Logging if bad input provided on lvl debug
 func (l *Log) BadInput(ctx context.Context, methodName string, params interface{}, err error) {
 	if err != nil {
-		l.LogAttrs(ctx, slog.LevelDebug, "musiclib: "+methodName,
-			slog.Any("params", params),
+		l.logCaller(ctx, slog.LevelDebug, "musiclib: "+methodName,
+			slog.Any("params", loggable(params)),
 			slog.String("error", err.Error()),
 		)
 	}
 }
 
-// func for formating songNullable for logging on lvl debug
-func (l *Log) FormatSongNullable(song entity.SongNullable) map[string]interface{} {
-	return map[string]interface{}{
-		"ID":          dereferencePointer(song.ID),
-		"Group":       dereferencePointer(song.Group),
-		"Title":       dereferencePointer(song.Title),
-		"ReleaseDate": dereferencePointer(song.ReleaseDate),
-		"Text":        dereferencePointer(song.Text),
-		"Link":        dereferencePointer(song.Link),
+// logCaller logs record with program counter of the caller of Standart or BadInput,
+// so level of its package is applied
+func (l *Log) logCaller(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if !l.Enabled(ctx, level) {
+		return
 	}
+	var pcs [1]uintptr
+	//skip runtime.Callers, logCaller and Standart/BadInput
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(attrs...)
+	_ = l.Handler().Handle(ctx, r)
+}
+
+// func for formating songNullable for logging on lvl debug, map can be nested in params,
+// where LogValue isn't called by handler
+func (l *Log) FormatSongNullable(song entity.SongNullable) map[string]interface{} {
+	group, _ := valueToAny(song.LogValue()).(map[string]interface{})
+	return group
 }
 
 // func for formating GetSongParams for logging on lvl debug
@@ -81,3 +167,28 @@ func dereferencePointer[T any](ptr *T) interface{} {
 	}
 	return *ptr
 }
+
+// loggable converts slices of slog.LogValuer, so their elements are redacted too.
+// slog resolves LogValuer only for the value of attribute itself
+func loggable(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice || !rv.Type().Elem().Implements(reflect.TypeFor[slog.LogValuer]()) {
+		return v
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = valueToAny(rv.Index(i).Interface().(slog.LogValuer).LogValue().Resolve())
+	}
+	return items
+}
+
+func valueToAny(v slog.Value) interface{} {
+	if v.Kind() != slog.KindGroup {
+		return v.Any()
+	}
+	group := make(map[string]interface{}, len(v.Group()))
+	for _, attr := range v.Group() {
+		group[attr.Key] = valueToAny(attr.Value.Resolve())
+	}
+	return group
+}
@@ -0,0 +1,170 @@
+// Redaction and truncation of sensitive and bulky values in logs.
+// Package has no dependencies on entity and logger, so both can use it
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// Policy of redaction, keys are matched case-insensitively
+type Policy struct {
+	// long texts are cut to this count of characters, 0 disables truncation
+	TextLimit int `env:"LOG_TEXT_LIMIT" envDefault:"64"`
+	// values of these keys are truncated
+	TruncateKeys []string `env:"LOG_TRUNCATE_KEYS" envSeparator:"," envDefault:"text,lyrics"`
+	// values of these keys are masked
+	MaskKeys []string `env:"LOG_MASK_KEYS" envSeparator:"," envDefault:"authorization,token,key,api_key,x-api-key,password,secret,bootstrap_key,jwt"`
+	// values of these keys are replaced with hash, emails in any string value are hashed too
+	HashKeys []string `env:"LOG_HASH_KEYS" envSeparator:"," envDefault:"email"`
+}
+
+// placeholder of masked value
+const Masked = "****"
+
+var emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// suffix of truncated text, value can pass truncation twice: in LogValue and in ReplaceAttr
+var truncatedRe = regexp.MustCompile(`\.\.\.\(\d+ chars\)$`)
+
+var current atomic.Pointer[Policy]
+
+func init() {
+	Configure(Policy{
+		TextLimit:    64,
+		TruncateKeys: []string{"text", "lyrics"},
+		MaskKeys:     []string{"authorization", "token", "key", "api_key", "x-api-key", "password", "secret", "bootstrap_key", "jwt"},
+		HashKeys:     []string{"email"},
+	})
+}
+
+// Configure replaces policy used by ReplaceAttr and helpers
+func Configure(p Policy) {
+	p.TruncateKeys = normalize(p.TruncateKeys)
+	p.MaskKeys = normalize(p.MaskKeys)
+	p.HashKeys = normalize(p.HashKeys)
+	current.Store(&p)
+}
+
+// Truncate cuts s to TextLimit characters, noting the original length
+func Truncate(s string) string {
+	limit := current.Load().TextLimit
+	length := utf8.RuneCountInString(s)
+	if limit <= 0 || length <= limit || truncatedRe.MatchString(s) {
+		return s
+	}
+	runes := []rune(s)
+	return fmt.Sprintf("%s...(%d chars)", string(runes[:limit]), length)
+}
+
+// Mask hides secret, keeping first 4 characters of long values to tell keys apart, e.g. "mlk_****"
+func Mask(s string) string {
+	if s == "" || strings.HasSuffix(s, Masked) {
+		return s
+	}
+	if len(s) >= 16 {
+		return s[:4] + Masked
+	}
+	return Masked
+}
+
+// Hash replaces value with short sha256, equal values give equal hashes
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// HashEmails replaces emails in s with their hashes
+func HashEmails(s string) string {
+	return emailRe.ReplaceAllStringFunc(s, Hash)
+}
+
+// ReplaceAttr applies policy to attribute, should be set as slog.HandlerOptions.ReplaceAttr.
+// Maps (e.g. params of logger.Standart) are redacted by their keys
+func ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+		return a
+	}
+	a.Value = value(a.Key, a.Value.Resolve())
+	return a
+}
+
+func value(key string, v slog.Value) slog.Value {
+	p := current.Load()
+	key = strings.ToLower(key)
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(redactString(p, key, v.String()))
+	case slog.KindAny:
+		switch v.Any().(type) {
+		case map[string]interface{}, map[string]string:
+			return slog.AnyValue(redactAny(p, key, v.Any()))
+		}
+	}
+	return v
+}
+
+// redactAny redacts strings by their keys, nested maps are redacted recursively
+func redactAny(p *Policy, key string, v interface{}) interface{} {
+	switch s := v.(type) {
+	case string:
+		return redactString(p, key, s)
+	case *string:
+		if s == nil {
+			return s
+		}
+		return redactString(p, key, *s)
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(s))
+		for k, item := range s {
+			redacted[k] = redactAny(p, strings.ToLower(k), item)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(s))
+		for k, item := range s {
+			redacted[k] = redactString(p, strings.ToLower(k), item)
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+func redactString(p *Policy, key string, s string) string {
+	switch {
+	case contains(p.MaskKeys, key):
+		return Mask(s)
+	case contains(p.HashKeys, key):
+		return Hash(s)
+	case contains(p.TruncateKeys, key):
+		return Truncate(HashEmails(s))
+	default:
+		return HashEmails(s)
+	}
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(keys []string) []string {
+	normalized := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			normalized = append(normalized, k)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,157 @@
+// Parsing of song text into sections: verses, choruses, bridges, intro and outro
+package lyrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+var (
+	// [Chorus], [Verse 2], [Chorus x2], [Bridge: Artist]
+	headerRe = regexp.MustCompile(`^\[([^\]]+)\]$`)
+	// Chorus:, Verse 1:, Chorus x2:
+	colonHeaderRe = regexp.MustCompile(`(?i)^((?:pre-?)?chorus|verse|bridge|intro|outro|refrain|hook)(\s+\d+)?(\s*[x×]\s*\d+)?\s*:$`)
+	// x2 or (x2) on its own line or in the header
+	repeatRe = regexp.MustCompile(`(?i)\(?\s*[x×]\s*(\d+)\s*\)?$`)
+)
+
+// keywords of header mapped to section type
+var headerTypes = []struct {
+	prefix string
+	typ    entity.SectionType
+}{
+	{"pre-chorus", entity.SectionPreChorus},
+	{"prechorus", entity.SectionPreChorus},
+	{"chorus", entity.SectionChorus},
+	{"refrain", entity.SectionChorus},
+	{"hook", entity.SectionChorus},
+	{"verse", entity.SectionVerse},
+	{"bridge", entity.SectionBridge},
+	{"intro", entity.SectionIntro},
+	{"outro", entity.SectionOutro},
+}
+
+// Parse splits text into sections by empty lines, several empty lines in a row separate sections as one.
+// Section type is taken from header markup like [Chorus] or Chorus:, blocks without header are choruses
+// if repeated in text and verses otherwise. A header without lines repeats the last section with the same header
+func Parse(text string) []entity.Section {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	sections := make([]entity.Section, 0)
+	//lines of labeled sections, for headers without lines
+	byLabel := make(map[string][]string)
+	marked := make([]bool, 0)
+	for _, block := range splitBlocks(text) {
+		lines := strings.Split(block, "\n")
+		section := entity.Section{Type: entity.SectionVerse, Repeat: 1}
+		hasHeader := false
+		if label, typ, repeat, ok := parseHeader(lines[0]); ok {
+			hasHeader = true
+			section.Label, section.Type, section.Repeat = label, typ, repeat
+			lines = lines[1:]
+		}
+		//trailing x2 line
+		if n := len(lines); n > 1 {
+			if m := repeatRe.FindStringSubmatch(strings.TrimSpace(lines[n-1])); m != nil && len(strings.TrimSpace(lines[n-1])) == len(m[0]) {
+				section.Repeat, _ = strconv.Atoi(m[1])
+				lines = lines[:n-1]
+			}
+		}
+		key := strings.ToLower(section.Label)
+		if len(lines) == 0 {
+			lines = append([]string{}, byLabel[key]...)
+		} else if hasHeader {
+			byLabel[key] = lines
+		}
+		section.Lines = lines
+		sections = append(sections, section)
+		marked = append(marked, hasHeader)
+	}
+
+	//blocks without markup sung more than once are choruses
+	counts := make(map[string]int, len(sections))
+	for _, s := range sections {
+		counts[normalize(s.Lines)]++
+	}
+	for i := range sections {
+		if !marked[i] && counts[normalize(sections[i].Lines)] > 1 {
+			sections[i].Type = entity.SectionChorus
+		}
+	}
+	return sections
+}
+
+// Blocks returns lines of sections, as text was paginated before sections were known
+func Blocks(sections []entity.Section) [][]string {
+	blocks := make([][]string, len(sections))
+	for i, s := range sections {
+		blocks[i] = s.Lines
+	}
+	return blocks
+}
+
+// ParseType validates section type from request
+func ParseType(s string) (entity.SectionType, bool) {
+	switch typ := entity.SectionType(strings.ToLower(s)); typ {
+	case entity.SectionIntro, entity.SectionVerse, entity.SectionPreChorus, entity.SectionChorus, entity.SectionBridge, entity.SectionOutro:
+		return typ, true
+	}
+	return "", false
+}
+
+// splitBlocks splits by one or more empty lines, like "\n\n" for texts without extra spacing
+func splitBlocks(text string) []string {
+	lines := strings.Split(text, "\n")
+	blocks := make([]string, 0)
+	current := make([]string, 0)
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = current[:0]
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	if len(blocks) == 0 {
+		//empty text is one empty verse, as before
+		blocks = append(blocks, "")
+	}
+	return blocks
+}
+
+func parseHeader(line string) (label string, typ entity.SectionType, repeat int, ok bool) {
+	line = strings.TrimSpace(line)
+	var inner string
+	if m := headerRe.FindStringSubmatch(line); m != nil {
+		inner = strings.TrimSpace(m[1])
+	} else if colonHeaderRe.MatchString(line) {
+		inner = strings.TrimSpace(strings.TrimSuffix(line, ":"))
+	} else {
+		return "", "", 0, false
+	}
+	repeat = 1
+	if m := repeatRe.FindStringSubmatchIndex(inner); m != nil {
+		repeat, _ = strconv.Atoi(inner[m[2]:m[3]])
+		inner = strings.TrimSpace(inner[:m[0]])
+	}
+	typ = entity.SectionVerse
+	lower := strings.ToLower(inner)
+	for _, h := range headerTypes {
+		if strings.HasPrefix(lower, h.prefix) {
+			typ = h.typ
+			break
+		}
+	}
+	return inner, typ, repeat, true
+}
+
+func normalize(lines []string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.Join(lines, " ")), " "))
+}
@@ -0,0 +1,121 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []entity.Section
+	}{
+		{
+			name: "empty text is one empty verse",
+			text: "",
+			want: []entity.Section{
+				{Type: entity.SectionVerse, Lines: []string{""}, Repeat: 1},
+			},
+		},
+		{
+			name: "blocks without markup are verses",
+			text: "first line\nsecond line\n\nthird line",
+			want: []entity.Section{
+				{Type: entity.SectionVerse, Lines: []string{"first line", "second line"}, Repeat: 1},
+				{Type: entity.SectionVerse, Lines: []string{"third line"}, Repeat: 1},
+			},
+		},
+		{
+			name: "bracket headers",
+			text: "[Verse 1]\nwalking\n\n[Pre-Chorus]\nwaiting\n\n[Chorus]\nsinging\n\n[Bridge: Guest]\nbreaking",
+			want: []entity.Section{
+				{Type: entity.SectionVerse, Label: "Verse 1", Lines: []string{"walking"}, Repeat: 1},
+				{Type: entity.SectionPreChorus, Label: "Pre-Chorus", Lines: []string{"waiting"}, Repeat: 1},
+				{Type: entity.SectionChorus, Label: "Chorus", Lines: []string{"singing"}, Repeat: 1},
+				{Type: entity.SectionBridge, Label: "Bridge: Guest", Lines: []string{"breaking"}, Repeat: 1},
+			},
+		},
+		{
+			name: "colon headers",
+			text: "Intro:\nhey\n\nprechorus:\nwait for it\n\nOutro:\nbye",
+			want: []entity.Section{
+				{Type: entity.SectionIntro, Label: "Intro", Lines: []string{"hey"}, Repeat: 1},
+				{Type: entity.SectionPreChorus, Label: "prechorus", Lines: []string{"wait for it"}, Repeat: 1},
+				{Type: entity.SectionOutro, Label: "Outro", Lines: []string{"bye"}, Repeat: 1},
+			},
+		},
+		{
+			name: "unmarked repeated block is chorus",
+			text: "verse one\n\nla la la\nOh oh\n\nverse two\n\nLa la  la\noh oh",
+			want: []entity.Section{
+				{Type: entity.SectionVerse, Lines: []string{"verse one"}, Repeat: 1},
+				{Type: entity.SectionChorus, Lines: []string{"la la la", "Oh oh"}, Repeat: 1},
+				{Type: entity.SectionVerse, Lines: []string{"verse two"}, Repeat: 1},
+				{Type: entity.SectionChorus, Lines: []string{"La la  la", "oh oh"}, Repeat: 1},
+			},
+		},
+		{
+			name: "marked block keeps its type when repeated",
+			text: "[Verse]\nsame\n\n[Verse]\nsame",
+			want: []entity.Section{
+				{Type: entity.SectionVerse, Label: "Verse", Lines: []string{"same"}, Repeat: 1},
+				{Type: entity.SectionVerse, Label: "Verse", Lines: []string{"same"}, Repeat: 1},
+			},
+		},
+		{
+			name: "repeat counts in header and trailing line",
+			text: "[Chorus x2]\nsing it\n\nhook line\n(x3)\n\nChorus ×4:\nagain",
+			want: []entity.Section{
+				{Type: entity.SectionChorus, Label: "Chorus", Lines: []string{"sing it"}, Repeat: 2},
+				{Type: entity.SectionVerse, Lines: []string{"hook line"}, Repeat: 3},
+				{Type: entity.SectionChorus, Label: "Chorus", Lines: []string{"again"}, Repeat: 4},
+			},
+		},
+		{
+			name: "header without lines repeats labeled section",
+			text: "[Chorus]\nsing it\nloud\n\n[Verse]\ntalk\n\n[Chorus]",
+			want: []entity.Section{
+				{Type: entity.SectionChorus, Label: "Chorus", Lines: []string{"sing it", "loud"}, Repeat: 1},
+				{Type: entity.SectionVerse, Label: "Verse", Lines: []string{"talk"}, Repeat: 1},
+				{Type: entity.SectionChorus, Label: "Chorus", Lines: []string{"sing it", "loud"}, Repeat: 1},
+			},
+		},
+		{
+			name: "crlf and several empty lines",
+			text: "[Verse]\r\nfirst\r\n\r\n\r\n  \r\n[Chorus]\r\nsecond\r\n",
+			want: []entity.Section{
+				{Type: entity.SectionVerse, Label: "Verse", Lines: []string{"first"}, Repeat: 1},
+				{Type: entity.SectionChorus, Label: "Chorus", Lines: []string{"second"}, Repeat: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want entity.SectionType
+		ok   bool
+	}{
+		{"chorus", entity.SectionChorus, true},
+		{"Pre-Chorus", entity.SectionPreChorus, true},
+		{"BRIDGE", entity.SectionBridge, true},
+		{"refrain", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := ParseType(tt.in); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseType(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
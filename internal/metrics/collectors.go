@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+// timeout of queries made on scrape
+const collectTimeout = 2 * time.Second
+
+type Storage interface {
+	Stat() *pgxpool.Stat
+	CountSongs(ctx context.Context) (int, error)
+}
+
+// poolCollector exposes pgxpool statistics
+type poolCollector struct {
+	storage Storage
+
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	totalConns      *prometheus.Desc
+	maxConns        *prometheus.Desc
+	acquireCount    *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	emptyAcquire    *prometheus.Desc
+	canceledAcquire *prometheus.Desc
+}
+
+func newPoolCollector(storage Storage) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		storage:         storage,
+		acquiredConns:   desc("acquired_connections", "Count of connections currently in use."),
+		idleConns:       desc("idle_connections", "Count of idle connections."),
+		totalConns:      desc("total_connections", "Count of all connections in pool."),
+		maxConns:        desc("max_connections", "Maximum size of pool."),
+		acquireCount:    desc("acquires_total", "Count of successful acquires from pool."),
+		acquireDuration: desc("acquire_wait_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquire:    desc("empty_acquires_total", "Count of acquires that waited for a connection because pool was empty."),
+		canceledAcquire: desc("canceled_acquires_total", "Count of acquires canceled by context."),
+	}
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.acquiredConns
+	ch <- p.idleConns
+	ch <- p.totalConns
+	ch <- p.maxConns
+	ch <- p.acquireCount
+	ch <- p.acquireDuration
+	ch <- p.emptyAcquire
+	ch <- p.canceledAcquire
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := p.storage.Stat()
+	ch <- prometheus.MustNewConstMetric(p.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(p.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(p.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(p.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(p.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(p.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(p.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
+
+// songsCollector exposes business gauges, values are queried on scrape
+type songsCollector struct {
+	storage Storage
+	log     *logger.Log
+	total   *prometheus.Desc
+}
+
+func newSongsCollector(storage Storage, l *logger.Log) *songsCollector {
+	return &songsCollector{
+		storage: storage,
+		log:     l,
+		total:   prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "songs_total"), "Count of songs in library.", nil, nil),
+	}
+}
+
+func (s *songsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.total
+}
+
+func (s *songsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	count, err := s.storage.CountSongs(ctx)
+	if err != nil {
+		//gauge is omitted from scrape, other metrics are still exposed
+		s.log.Error("metrics: failed to count songs", "error", err.Error())
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(s.total, prometheus.GaugeValue, float64(count))
+}
@@ -0,0 +1,118 @@
+// Prometheus metrics of http api, postgres pool and external api calls
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/operation"
+)
+
+const namespace = "musiclib"
+
+type Config struct {
+	Enabled bool   `env:"METRICS_ENABLED"`
+	Path    string `env:"METRICS_PATH" envDefault:"/metrics"`
+}
+
+type Metrics struct {
+	cfg      Config
+	registry *prometheus.Registry
+	log      *logger.Log
+
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	upstreamRequests *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+}
+
+// New creates metrics. storage is used for pool statistics and business gauges, can be nil
+func New(cfg Config, storage Storage, l *logger.Log) *Metrics {
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+	m := &Metrics{
+		cfg:      cfg,
+		registry: prometheus.NewRegistry(),
+		log:      l,
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Count of http requests by api operation and status.",
+		}, []string{"operation", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of http requests by api operation and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status"}),
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "upstream",
+			Name:      "requests_total",
+			Help:      "Count of external api calls by outcome.",
+		}, []string{"outcome"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "upstream",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of external api calls by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.upstreamRequests,
+		m.upstreamDuration,
+	)
+	if storage != nil {
+		m.registry.MustRegister(newPoolCollector(storage), newSongsCollector(storage, l))
+	}
+	return m
+}
+
+// Register adds metrics endpoint to router
+func (m *Metrics) Register(r gin.IRoutes) {
+	if !m.cfg.Enabled {
+		return
+	}
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+	r.GET(m.cfg.Path, gin.WrapH(handler))
+}
+
+// Middleware counts requests and their latency per api.ServerInterface operation.
+// Should be registered on router, so rejected by api middlewares requests are counted too
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.cfg.Enabled {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+		if c.FullPath() == m.cfg.Path {
+			return
+		}
+		op := operation.Name(c)
+		if op == "" {
+			//request to unknown path, keeping label cardinality bounded
+			op = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(op, status).Inc()
+		m.httpDuration.WithLabelValues(op, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/repository/upstream"
+)
+
+type Upstream interface {
+	GetSongDetail(ctx context.Context, group string, title string) (entity.Song, error)
+}
+
+type instrumentedUpstream struct {
+	next    Upstream
+	metrics *Metrics
+}
+
+// InstrumentUpstream returns external api adapter measuring latency and outcome of calls
+func (m *Metrics) InstrumentUpstream(next Upstream) Upstream {
+	if !m.cfg.Enabled {
+		return next
+	}
+	return &instrumentedUpstream{next: next, metrics: m}
+}
+
+func (u *instrumentedUpstream) GetSongDetail(ctx context.Context, group string, title string) (entity.Song, error) {
+	start := time.Now()
+	song, err := u.next.GetSongDetail(ctx, group, title)
+	outcome := outcome(err)
+	u.metrics.upstreamRequests.WithLabelValues(outcome).Inc()
+	u.metrics.upstreamDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+	return song, err
+}
+
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, upstream.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, upstream.ErrContractViolation):
+		return "contract_violation"
+	case errors.Is(err, upstream.ErrUnavailable):
+		return "unavailable"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,85 @@
+package musiclib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/tracing"
+)
+
+// CreateAPIKey generates new key. Returned key has plain Key, it can't be got later
+func (m *MusicLib) CreateAPIKey(ctx context.Context, name string, role string) (key entity.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.CreateAPIKey")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		params := map[string]string{
+			"name": name,
+			"role": role,
+		}
+		if errors.Is(err, ErrInvalidParams) {
+			m.log.BadInput(ctx, "musiclib: CreateAPIKey", params, err)
+			return
+		}
+		m.log.Standart(ctx, "musiclib: CreateAPIKey", params, key.ID, err)
+	}()
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return entity.APIKey{}, fmt.Errorf("name of the key is empty: %w", ErrInvalidParams)
+	}
+	parsedRole, err := entity.ParseRole(role)
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("%w: %w", ErrInvalidParams, err)
+	}
+	plain, hash, err := entity.GenerateKey()
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+	key, err = m.storage.CreateAPIKey(ctx, entity.APIKey{Name: name, Role: string(parsedRole), Hash: hash})
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("db error: %w", err)
+	}
+	key.Key = plain
+	return key, nil
+}
+
+func (m *MusicLib) GetAPIKeys(ctx context.Context) (keys []entity.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.GetAPIKeys")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		m.log.Standart(ctx, "musiclib: GetAPIKeys", nil, len(keys), err)
+	}()
+	keys, err = m.storage.SelectAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return keys, nil
+}
+
+func (m *MusicLib) RevokeAPIKey(ctx context.Context, id int) (err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.RevokeAPIKey")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			m.log.BadInput(ctx, "musiclib: RevokeAPIKey", id, err)
+			return
+		}
+		m.log.Standart(ctx, "musiclib: RevokeAPIKey", id, nil, err)
+	}()
+	if err := m.storage.RevokeAPIKey(ctx, id); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return fmt.Errorf("db didn't find active key with id %d: %w", id, ErrAPIKeyNotFound)
+		}
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
@@ -4,3 +4,6 @@ import "errors"
 
 var ErrSongNotFound = errors.New("song not found")
 var ErrInvalidParams = errors.New("invalid params")
+var ErrProposalNotFound = errors.New("proposal not found")
+var ErrAPIKeyNotFound = errors.New("api key not found")
+var ErrSongLocked = errors.New("song is locked")
@@ -5,11 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+
+	"go.opentelemetry.io/otel"
 
 	"github.com/Rolan335/Musiclib/internal/entity"
 	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/lyrics"
 	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/tracing"
 )
 
 type Storage interface {
@@ -18,8 +21,18 @@ type Storage interface {
 	DeleteSong(ctx context.Context, id int) error
 	UpdateSong(ctx context.Context, id int, song entity.SongNullable) error
 	GetSong(ctx context.Context, id int) (entity.Song, error)
+	SelectSongsWithoutLyrics(ctx context.Context, afterID int, limit int) ([]entity.Song, error)
+	UpdateLyrics(ctx context.Context, id int, text string, lyrics []entity.Section) error
+	SelectProposals(ctx context.Context) ([]entity.Proposal, error)
+	GetProposal(ctx context.Context, id int) (entity.Proposal, error)
+	DeleteProposal(ctx context.Context, id int) error
+	CreateAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	SelectAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
 }
 
+var tracer = otel.Tracer("github.com/Rolan335/Musiclib/internal/musiclib")
+
 type MusicLib struct {
 	storage Storage
 	log     *logger.Log
@@ -33,6 +46,10 @@ func NewMusicLib(storage Storage, l *logger.Log) *MusicLib {
 }
 
 func (m *MusicLib) GetSongs(ctx context.Context, params entity.GetSongsParams) (songs []entity.Song, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.GetSongs")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	defer func() {
 		m.log.Standart(ctx, "musiclib: GetSongs", m.log.FormatGetSongParams(params), songs, err)
 	}()
@@ -45,6 +62,9 @@ func (m *MusicLib) GetSongs(ctx context.Context, params entity.GetSongsParams) (
 		params.PageSize = new(int)
 		*params.PageSize = 10
 	}
+	if *params.Page < 1 || *params.PageSize < 1 {
+		return nil, fmt.Errorf("page and pageSize should be positive: %w", ErrInvalidParams)
+	}
 	songs, err = m.storage.SelectSongs(ctx, params)
 	if err != nil {
 		return nil, err
@@ -54,9 +74,14 @@ func (m *MusicLib) GetSongs(ctx context.Context, params entity.GetSongsParams) (
 
 // returns id
 func (m *MusicLib) CreateSong(ctx context.Context, song entity.Song) (songID int, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.CreateSong")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	defer func() {
 		m.log.Standart(ctx, "musiclib: CreateSong", song, songID, err)
 	}()
+	song.Lyrics = lyrics.Parse(song.Text)
 	songID, err = m.storage.CreateSong(ctx, song)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create song: %w", err)
@@ -65,6 +90,10 @@ func (m *MusicLib) CreateSong(ctx context.Context, song entity.Song) (songID int
 }
 
 func (m *MusicLib) DeleteSong(ctx context.Context, id int) (err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.DeleteSong")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	defer func() {
 		if errors.Is(err, ErrSongNotFound) {
 			m.log.BadInput(ctx, "musiclib: DeleteSong", id, err)
@@ -83,6 +112,10 @@ func (m *MusicLib) DeleteSong(ctx context.Context, id int) (err error) {
 }
 
 func (m *MusicLib) UpdateSong(ctx context.Context, id int, song entity.SongNullable) (err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.UpdateSong")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	defer func() {
 		if errors.Is(err, ErrSongNotFound) {
 			m.log.BadInput(ctx, "musiclib: UpdateSong", m.log.FormatSongNullable(song), err)
@@ -90,6 +123,9 @@ func (m *MusicLib) UpdateSong(ctx context.Context, id int, song entity.SongNulla
 		}
 		m.log.Standart(ctx, "musiclib: UpdateSong", m.log.FormatSongNullable(song), nil, err)
 	}()
+	if song.Text != nil {
+		song.Lyrics = lyrics.Parse(*song.Text)
+	}
 	err = m.storage.UpdateSong(ctx, id, song)
 	if err != nil {
 		if errors.Is(err, postgres.ErrNotFound) {
@@ -100,12 +136,40 @@ func (m *MusicLib) UpdateSong(ctx context.Context, id int, song entity.SongNulla
 	return nil
 }
 
-func (m *MusicLib) GetSongText(ctx context.Context, id int, page int, pageSize int) (text entity.Text, err error) {
+func (m *MusicLib) GetSong(ctx context.Context, id int) (song entity.Song, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.GetSong")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		if errors.Is(err, ErrSongNotFound) {
+			m.log.BadInput(ctx, "musiclib: GetSong", id, err)
+			return
+		}
+		m.log.Standart(ctx, "musiclib: GetSong", id, song, err)
+	}()
+	song, err = m.storage.GetSong(ctx, id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return entity.Song{}, fmt.Errorf("db didn't find song with id %d: %w", id, ErrSongNotFound)
+		}
+		return entity.Song{}, fmt.Errorf("db error: %w", err)
+	}
+	return song, nil
+}
+
+// GetSongText returns page of sections of the song, only of sectionType if it's not empty
+func (m *MusicLib) GetSongText(ctx context.Context, id int, page int, pageSize int, sectionType string) (text entity.Text, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.GetSongText")
+	defer func() {
+		tracing.End(span, err)
+	}()
 	defer func() {
-		params := map[string]int{
+		params := map[string]interface{}{
 			"id":       id,
 			"page":     page,
 			"pageSize": pageSize,
+			"section":  sectionType,
 		}
 		if errors.Is(err, ErrSongNotFound) || errors.Is(err, ErrInvalidParams) {
 			m.log.BadInput(ctx, "musiclib: GetSongText", params, err)
@@ -113,6 +177,16 @@ func (m *MusicLib) GetSongText(ctx context.Context, id int, page int, pageSize i
 		}
 		m.log.Standart(ctx, "musiclib: GetSongText", params, text, err)
 	}()
+	if page < 1 || pageSize < 1 {
+		return entity.Text{}, fmt.Errorf("page and pageSize should be positive: %w", ErrInvalidParams)
+	}
+	var typ entity.SectionType
+	if sectionType != "" {
+		var ok bool
+		if typ, ok = lyrics.ParseType(sectionType); !ok {
+			return entity.Text{}, fmt.Errorf("unknown section type %q: %w", sectionType, ErrInvalidParams)
+		}
+	}
 	song, err := m.storage.GetSong(ctx, id)
 	if err != nil {
 		if errors.Is(err, postgres.ErrNotFound) {
@@ -120,26 +194,64 @@ func (m *MusicLib) GetSongText(ctx context.Context, id int, page int, pageSize i
 		}
 		return entity.Text{}, fmt.Errorf("db error: %w", err)
 	}
-	//Pagination, split verses by \n\n
-	//page - from which verse start (offset)
-	//pageSize - how much verses to take (limit)
-	//example - page=1 pageSize=2 - returns 2 verses from 1 included
-	verses := strings.Split(song.Text, "\n\n")
-	if page > len(verses) {
-		return entity.Text{}, fmt.Errorf("page is bigger than number of verses: %w", ErrInvalidParams)
+	sections := song.Lyrics
+	if sections == nil {
+		//not backfilled yet, parsed without storing, reads don't write
+		sections = lyrics.Parse(song.Text)
 	}
-	startIndex := page - 1
-	endIndex := startIndex + pageSize
-	if endIndex > len(verses) {
-		endIndex = len(verses)
+	if typ != "" {
+		filtered := make([]entity.Section, 0, len(sections))
+		for _, s := range sections {
+			if s.Type == typ {
+				filtered = append(filtered, s)
+			}
+		}
+		if len(filtered) == 0 {
+			//explicit empty arrays, not an empty object
+			return entity.Text{Text: [][]string{}, Sections: []entity.Section{}}, nil
+		}
+		sections = filtered
 	}
-	newSlice := make([][]string, len(verses[startIndex:endIndex]))
-	fmt.Println(len(newSlice))
-	for i := range newSlice {
-		newSlice[i] = strings.Split(verses[startIndex:endIndex][i], "\n")
+	//Pagination by sections (verses separated by \n\n)
+	//page - from which section start (offset)
+	//pageSize - how much sections to take (limit)
+	//example - page=1 pageSize=2 - returns 2 sections from 1 included
+	if page > len(sections) {
+		return entity.Text{}, fmt.Errorf("page is bigger than number of verses: %w", ErrInvalidParams)
 	}
+	startIndex := page - 1
+	endIndex := min(startIndex+pageSize, len(sections))
 	text = entity.Text{
-		Text: newSlice,
+		Text:     lyrics.Blocks(sections[startIndex:endIndex]),
+		Sections: sections[startIndex:endIndex],
 	}
 	return text, nil
 }
+
+// BackfillLyrics parses and stores sections of songs added before lyrics were parsed on write
+func (m *MusicLib) BackfillLyrics(ctx context.Context, batchSize int) (updated int, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.BackfillLyrics")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		m.log.Standart(ctx, "musiclib: BackfillLyrics", batchSize, updated, err)
+	}()
+	lastID := 0
+	for {
+		songs, err := m.storage.SelectSongsWithoutLyrics(ctx, lastID, batchSize)
+		if err != nil {
+			return updated, fmt.Errorf("db error: %w", err)
+		}
+		for _, song := range songs {
+			lastID = song.ID
+			if err := m.storage.UpdateLyrics(ctx, song.ID, song.Text, lyrics.Parse(song.Text)); err != nil {
+				return updated, fmt.Errorf("db error: %w", err)
+			}
+			updated++
+		}
+		if len(songs) < batchSize {
+			return updated, nil
+		}
+	}
+}
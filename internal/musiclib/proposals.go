@@ -0,0 +1,102 @@
+package musiclib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/lyrics"
+	"github.com/Rolan335/Musiclib/internal/repository/postgres"
+	"github.com/Rolan335/Musiclib/internal/tracing"
+)
+
+// GetProposals returns changes found by resync, waiting for review
+func (m *MusicLib) GetProposals(ctx context.Context) (proposals []entity.Proposal, err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.GetProposals")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		m.log.Standart(ctx, "musiclib: GetProposals", nil, proposals, err)
+	}()
+	proposals, err = m.storage.SelectProposals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("db error: %w", err)
+	}
+	return proposals, nil
+}
+
+// ApplyProposal writes proposed changes to the song and removes proposal
+func (m *MusicLib) ApplyProposal(ctx context.Context, id int) (err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.ApplyProposal")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		if errors.Is(err, ErrProposalNotFound) || errors.Is(err, ErrSongNotFound) || errors.Is(err, ErrSongLocked) {
+			m.log.BadInput(ctx, "musiclib: ApplyProposal", id, err)
+			return
+		}
+		m.log.Standart(ctx, "musiclib: ApplyProposal", id, nil, err)
+	}()
+	proposal, err := m.storage.GetProposal(ctx, id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return fmt.Errorf("db didn't find proposal with id %d: %w", id, ErrProposalNotFound)
+		}
+		return fmt.Errorf("db error: %w", err)
+	}
+	//lock is checked on primary, replica may lag behind
+	song, err := m.storage.GetSong(postgres.WithPrimary(ctx), proposal.SongID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return fmt.Errorf("db didn't find song with id %d: %w", proposal.SongID, ErrSongNotFound)
+		}
+		return fmt.Errorf("db error: %w", err)
+	}
+	if song.Locked {
+		return fmt.Errorf("song %d is locked, unlock it or reject proposal: %w", song.ID, ErrSongLocked)
+	}
+	changes := entity.SongNullable{
+		ReleaseDate: proposal.ReleaseDate,
+		Text:        proposal.Text,
+		Link:        proposal.Link,
+	}
+	if proposal.Text != nil {
+		changes.Lyrics = lyrics.Parse(*proposal.Text)
+	}
+	err = m.storage.UpdateSong(ctx, proposal.SongID, changes)
+	if err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return fmt.Errorf("db didn't find song with id %d: %w", proposal.SongID, ErrSongNotFound)
+		}
+		return fmt.Errorf("db error: %w", err)
+	}
+	if err := m.storage.DeleteProposal(ctx, id); err != nil && !errors.Is(err, postgres.ErrNotFound) {
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
+
+// RejectProposal removes proposal without changing the song
+func (m *MusicLib) RejectProposal(ctx context.Context, id int) (err error) {
+	ctx, span := tracer.Start(ctx, "MusicLib.RejectProposal")
+	defer func() {
+		tracing.End(span, err)
+	}()
+	defer func() {
+		if errors.Is(err, ErrProposalNotFound) {
+			m.log.BadInput(ctx, "musiclib: RejectProposal", id, err)
+			return
+		}
+		m.log.Standart(ctx, "musiclib: RejectProposal", id, nil, err)
+	}()
+	if err := m.storage.DeleteProposal(ctx, id); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return fmt.Errorf("db didn't find proposal with id %d: %w", id, ErrProposalNotFound)
+		}
+		return fmt.Errorf("db error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+// Name of the api.ServerInterface operation handling request
+package operation
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Name returns name of api.ServerInterface method serving request, e.g. "GetSongs".
+// Routes not registered with api.RegisterHandlers return their path.
+// Works from middlewares of the route, because handler name is resolved by gin before chain starts
+func Name(c *gin.Context) string {
+	handler := c.HandlerName()
+	if !strings.Contains(handler, "ServerInterfaceWrapper") {
+		return c.FullPath()
+	}
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
@@ -0,0 +1,73 @@
+// RFC 7807 problem details responses
+package problem
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/requestid"
+)
+
+const ContentType = "application/problem+json"
+
+// Stable machine-readable codes of problems. Codes are part of api contract, don't rename them
+const (
+	CodeInvalidParameter          = "invalid_parameter"
+	CodeInvalidBody               = "invalid_body"
+	CodeValidationFailed          = "validation_failed"
+	CodeUnauthorized              = "unauthorized"
+	CodeForbidden                 = "forbidden"
+	CodeNotFound                  = "not_found"
+	CodeSongNotFound              = "song_not_found"
+	CodeProposalNotFound          = "proposal_not_found"
+	CodeAPIKeyNotFound            = "api_key_not_found"
+	CodeSongLocked                = "song_locked"
+	CodeRateLimited               = "rate_limited"
+	CodeUpstreamSongNotFound      = "upstream_song_not_found"
+	CodeUpstreamContractViolation = "upstream_contract_violation"
+	CodeUpstreamUnavailable       = "upstream_unavailable"
+	CodeTimeout                   = "timeout"
+	CodeInternal                  = "internal"
+)
+
+// FieldError describes invalid field of request or upstream response
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func New(status int, code string, detail string) Problem {
+	return Problem{
+		Type:   "/problems/" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// WithErrors adds field level errors
+func (p Problem) WithErrors(errors ...FieldError) Problem {
+	p.Errors = append(p.Errors, errors...)
+	return p
+}
+
+// Abort writes problem to response and aborts handler chain
+func Abort(c *gin.Context, p Problem) {
+	p.Instance = c.Request.URL.Path
+	p.RequestID = requestid.FromContext(c.Request.Context())
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
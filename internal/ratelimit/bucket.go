@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket is token bucket refilled with rate tokens per second up to burst
+type bucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// take removes one token if available. Returns remaining tokens and time until next token
+func (b *bucket) take(now time.Time, rate float64, burst int) (bool, int, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	}
+	b.last = now
+	b.lastSeen = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// untilFull returns time needed to refill bucket completely
+func (b *bucket) untilFull(rate float64, burst int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second))
+}
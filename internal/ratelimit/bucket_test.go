@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after     time.Duration
+		allowed   bool
+		remaining int
+		wait      time.Duration
+	}{
+		//full bucket on first request
+		{after: 0, allowed: true, remaining: 2},
+		{after: 0, allowed: true, remaining: 1},
+		{after: 0, allowed: true, remaining: 0},
+		{after: 0, allowed: false, wait: 500 * time.Millisecond},
+		{after: 250 * time.Millisecond, allowed: false, wait: 250 * time.Millisecond},
+		{after: 500 * time.Millisecond, allowed: true, remaining: 0},
+		//refill is capped by burst
+		{after: time.Hour, allowed: true, remaining: 2},
+	}
+	var b bucket
+	now := start
+	for i, s := range steps {
+		now = now.Add(s.after)
+		allowed, remaining, wait := b.take(now, 2, 3)
+		if allowed != s.allowed || remaining != s.remaining || wait != s.wait {
+			t.Errorf("step %d: take() = %v, %d, %v, want %v, %d, %v", i, allowed, remaining, wait, s.allowed, s.remaining, s.wait)
+		}
+	}
+	if got := b.untilFull(2, 3); got != 500*time.Millisecond {
+		t.Errorf("untilFull() = %v, want 500ms", got)
+	}
+}
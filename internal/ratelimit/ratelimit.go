@@ -0,0 +1,228 @@
+// Per-client rate limiting with token buckets and optional daily quotas
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/auth"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/operation"
+	"github.com/Rolan335/Musiclib/internal/problem"
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+// Class of operations with separate limits
+type Class string
+
+const (
+	ClassRead  Class = "read"
+	ClassWrite Class = "write"
+	// operations calling external api on our budget
+	ClassUpstream Class = "upstream"
+)
+
+// UpstreamOperations are operations of api.ServerInterface calling external api
+var UpstreamOperations = map[string]bool{
+	"PostSongs": true,
+}
+
+// buckets not used for this time are removed
+const idleTimeout = 10 * time.Minute
+
+type Limit struct {
+	// tokens per second, 0 disables limit for the class
+	RPS   float64 `env:"RPS"`
+	Burst int     `env:"BURST"`
+	// requests per client per day (UTC), 0 disables quota
+	DailyQuota int `env:"DAILY_QUOTA"`
+}
+
+type Config struct {
+	Enabled bool `env:"RATE_LIMIT_ENABLED"`
+	// limit per ip for all requests, checked before authentication, so floods of invalid credentials
+	// don't reach key storage. 0 disables it
+	IPRPS    float64 `env:"RATE_LIMIT_IP_RPS"`
+	IPBurst  int     `env:"RATE_LIMIT_IP_BURST"`
+	Read     Limit   `envPrefix:"RATE_LIMIT_READ_"`
+	Write    Limit   `envPrefix:"RATE_LIMIT_WRITE_"`
+	Upstream Limit   `envPrefix:"RATE_LIMIT_UPSTREAM_"`
+}
+
+type QuotaStorage interface {
+	IncrementQuota(ctx context.Context, client string, class string, day time.Time) (int, error)
+	DeleteQuotasBefore(ctx context.Context, day time.Time) (int64, error)
+}
+
+type Limiter struct {
+	enabled bool
+	ip      Limit
+	limits  map[Class]Limit
+	quotas  QuotaStorage
+	log     *logger.Log
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	// day of the last removal of past quotas
+	prunedDay time.Time
+}
+
+func NewLimiter(cfg Config, quotas QuotaStorage, l *logger.Log) *Limiter {
+	limits := map[Class]Limit{
+		ClassRead:     cfg.Read,
+		ClassWrite:    cfg.Write,
+		ClassUpstream: cfg.Upstream,
+	}
+	for class, limit := range limits {
+		if limit.RPS > 0 && limit.Burst < 1 {
+			limit.Burst = int(math.Max(1, math.Ceil(limit.RPS)))
+			limits[class] = limit
+		}
+	}
+	ip := Limit{RPS: cfg.IPRPS, Burst: cfg.IPBurst}
+	if ip.RPS > 0 && ip.Burst < 1 {
+		ip.Burst = int(math.Max(1, math.Ceil(ip.RPS)))
+	}
+	return &Limiter{
+		enabled: cfg.Enabled,
+		ip:      ip,
+		limits:  limits,
+		quotas:  quotas,
+		log:     l,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// IPMiddleware limits all requests per ip. Should be registered before auth middlewares
+func (l *Limiter) IPMiddleware() api.MiddlewareFunc {
+	return func(c *gin.Context) {
+		if !l.enabled || l.ip.RPS <= 0 {
+			return
+		}
+		client := "ip:" + c.ClientIP()
+		now := time.Now()
+		allowed, _, wait := l.bucket(client, now).take(now, l.ip.RPS, l.ip.Burst)
+		if !allowed {
+			l.reject(c, client, "ip", wait, "rate limit exceeded")
+		}
+	}
+}
+
+// Middleware limits requests per client and class. Should be registered after auth middlewares,
+// so clients are identified by api key or jwt subject instead of ip
+func (l *Limiter) Middleware() api.MiddlewareFunc {
+	return func(c *gin.Context) {
+		if !l.enabled {
+			return
+		}
+		class := Classify(c)
+		limit := l.limits[class]
+		client := auth.CallerKey(c)
+		now := time.Now()
+
+		if limit.RPS > 0 {
+			b := l.bucket(client+"|"+string(class), now)
+			allowed, remaining, wait := b.take(now, limit.RPS, limit.Burst)
+			c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Burst, int(math.Ceil(float64(limit.Burst)/limit.RPS))))
+			c.Header("RateLimit-Limit", strconv.Itoa(limit.Burst))
+			c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+			c.Header("RateLimit-Reset", seconds(b.untilFull(limit.RPS, limit.Burst)))
+			if !allowed {
+				l.reject(c, client, class, wait, "rate limit exceeded")
+				return
+			}
+		}
+
+		if limit.DailyQuota > 0 && l.quotas != nil {
+			day := now.UTC().Truncate(24 * time.Hour)
+			l.prune(day)
+			count, err := l.quotas.IncrementQuota(c.Request.Context(), client, string(class), day)
+			if err != nil {
+				//quota is best effort, storage failure shouldn't make api unavailable
+				l.log.Error("ratelimit: failed to increment quota", "client", client, "error", err.Error())
+				return
+			}
+			c.Header("RateLimit-Quota-Limit", strconv.Itoa(limit.DailyQuota))
+			c.Header("RateLimit-Quota-Remaining", strconv.Itoa(max(limit.DailyQuota-count, 0)))
+			if count > limit.DailyQuota {
+				l.reject(c, client, class, day.Add(24*time.Hour).Sub(now), "daily quota exceeded")
+				return
+			}
+		}
+	}
+}
+
+// prune removes quotas of past days in background, once a day
+func (l *Limiter) prune(today time.Time) {
+	l.mu.Lock()
+	if !l.prunedDay.Before(today) {
+		l.mu.Unlock()
+		return
+	}
+	l.prunedDay = today
+	l.mu.Unlock()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		deleted, err := l.quotas.DeleteQuotasBefore(ctx, today)
+		if err != nil {
+			l.log.Error("ratelimit: failed to delete past quotas", "error", err.Error())
+			return
+		}
+		l.log.Info("ratelimit: past quotas deleted", "rows", deleted)
+	}()
+}
+
+func (l *Limiter) reject(c *gin.Context, client string, class Class, retryAfter time.Duration, reason string) {
+	l.log.Info("ratelimit: request rejected", "client", client, "class", string(class), "reason", reason)
+	c.Header("Retry-After", seconds(retryAfter))
+	problem.Abort(c, problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, reason))
+}
+
+func (l *Limiter) bucket(key string, now time.Time) *bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > idleTimeout {
+		for k, b := range l.buckets {
+			b.mu.Lock()
+			idle := now.Sub(b.lastSeen) > idleTimeout
+			b.mu.Unlock()
+			if idle {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// Classify returns class of operation serving request
+func Classify(c *gin.Context) Class {
+	if UpstreamOperations[operation.Name(c)] {
+		return ClassUpstream
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ClassRead
+	default:
+		return ClassWrite
+	}
+}
+
+// seconds rounds duration up to whole seconds
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
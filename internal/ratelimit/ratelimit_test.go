@@ -0,0 +1,173 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/auth"
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+// quotas counts requests in memory, pruned receives days passed to DeleteQuotasBefore
+type quotas struct {
+	mu     sync.Mutex
+	counts map[string]int
+	err    error
+	pruned chan time.Time
+}
+
+func newQuotas() *quotas {
+	return &quotas{counts: make(map[string]int), pruned: make(chan time.Time, 10)}
+}
+
+func (q *quotas) IncrementQuota(_ context.Context, client string, class string, _ time.Time) (int, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.counts[client+"|"+class]++
+	return q.counts[client+"|"+class], nil
+}
+
+func (q *quotas) DeleteQuotasBefore(_ context.Context, day time.Time) (int64, error) {
+	q.pruned <- day
+	return 0, nil
+}
+
+// router serves GET and POST /songs behind limiter, X-Key header stands for api key of the caller
+func router(l *Limiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	identify := func(c *gin.Context) {
+		if key := c.GetHeader("X-Key"); key != "" {
+			identity := auth.Identity{Subject: key, Method: auth.MethodJWT, Role: entity.RoleAdmin}
+			c.Request = c.Request.WithContext(auth.WithIdentity(c.Request.Context(), identity))
+		}
+	}
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	chain := []gin.HandlerFunc{gin.HandlerFunc(l.IPMiddleware()), identify, gin.HandlerFunc(l.Middleware()), ok}
+	r.GET("/songs", chain...)
+	r.POST("/songs", chain...)
+	return r
+}
+
+func send(r *gin.Engine, method string, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/songs", nil)
+	req.RemoteAddr = "192.0.2.1:4000"
+	if key != "" {
+		req.Header.Set("X-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	l := NewLimiter(Config{
+		Enabled: true,
+		Read:    Limit{RPS: 0.001, Burst: 2},
+		Write:   Limit{RPS: 0.001},
+	}, nil, logger.New("error", io.Discard))
+	r := router(l)
+
+	steps := []struct {
+		method string
+		key    string
+		status int
+	}{
+		{http.MethodGet, "alice", http.StatusOK},
+		{http.MethodGet, "alice", http.StatusOK},
+		{http.MethodGet, "alice", http.StatusTooManyRequests},
+		//other caller and other class have own buckets
+		{http.MethodGet, "bob", http.StatusOK},
+		{http.MethodPost, "alice", http.StatusOK},
+		//burst defaults to 1 when only rps is set
+		{http.MethodPost, "alice", http.StatusTooManyRequests},
+		//anonymous callers are limited by ip
+		{http.MethodGet, "", http.StatusOK},
+	}
+	for i, s := range steps {
+		w := send(r, s.method, s.key)
+		if w.Code != s.status {
+			t.Fatalf("step %d: status = %d, want %d", i, w.Code, s.status)
+		}
+		if s.status == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("step %d: Retry-After is missing", i)
+		}
+		if s.method == http.MethodGet && w.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("step %d: RateLimit-Limit = %q, want 2", i, w.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
+
+func TestIPMiddleware(t *testing.T) {
+	l := NewLimiter(Config{Enabled: true, IPRPS: 0.001, IPBurst: 1}, nil, logger.New("error", io.Discard))
+	r := router(l)
+	if w := send(r, http.MethodGet, "alice"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	//limited before authentication, key doesn't give separate bucket
+	if w := send(r, http.MethodPost, "bob"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+}
+
+func TestDailyQuota(t *testing.T) {
+	q := newQuotas()
+	l := NewLimiter(Config{Enabled: true, Read: Limit{DailyQuota: 2}}, q, logger.New("error", io.Discard))
+	r := router(l)
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := send(r, http.MethodGet, "alice")
+		if w.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, want)
+		}
+		if w.Header().Get("RateLimit-Quota-Limit") != "2" {
+			t.Errorf("request %d: RateLimit-Quota-Limit = %q", i, w.Header().Get("RateLimit-Quota-Limit"))
+		}
+	}
+	if w := send(r, http.MethodGet, "bob"); w.Code != http.StatusOK {
+		t.Errorf("other caller: status = %d, want 200", w.Code)
+	}
+
+	//past quotas are removed once a day
+	select {
+	case day := <-q.pruned:
+		if want := time.Now().UTC().Truncate(24 * time.Hour); !day.Equal(want) {
+			t.Errorf("pruned before %v, want %v", day, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("past quotas weren't pruned")
+	}
+	select {
+	case <-q.pruned:
+		t.Error("quotas were pruned twice a day")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	//storage failure doesn't make api unavailable
+	q.err = errors.New("connection refused")
+	if w := send(r, http.MethodGet, "alice"); w.Code != http.StatusOK {
+		t.Errorf("quota storage is down: status = %d, want 200", w.Code)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	l := NewLimiter(Config{Enabled: false, IPRPS: 0.001, IPBurst: 1, Read: Limit{RPS: 0.001, Burst: 1}}, nil, logger.New("error", io.Discard))
+	r := router(l)
+	for i := 0; i < 3; i++ {
+		if w := send(r, http.MethodGet, "alice"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i, w.Code)
+		}
+	}
+}
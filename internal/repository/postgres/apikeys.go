@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+func (s *Storage) CreateAPIKey(ctx context.Context, key entity.APIKey) (created entity.APIKey, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: CreateAPIKey", key.Name, created.ID, err)
+	}()
+	query := `INSERT INTO api_keys (name, role, key_hash) VALUES ($1, $2, $3) RETURNING id, name, role, created_at`
+	if err := s.db.QueryRow(ctx, query, key.Name, key.Role, key.Hash).
+		Scan(&created.ID, &created.Name, &created.Role, &created.CreatedAt); err != nil {
+		return entity.APIKey{}, fmt.Errorf("failed to exec insert: %w", err)
+	}
+	return created, nil
+}
+
+func (s *Storage) SelectAPIKeys(ctx context.Context) (keys []entity.APIKey, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: SelectAPIKeys", nil, len(keys), err)
+	}()
+	rows, err := s.db.Query(ctx, `SELECT id, name, role, created_at, revoked_at FROM api_keys ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	defer rows.Close()
+	keys = make([]entity.APIKey, 0)
+	for rows.Next() {
+		var key entity.APIKey
+		if err := rows.Scan(&key.ID, &key.Name, &key.Role, &key.CreatedAt, &key.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error in row: %w", rows.Err())
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByHash returns not revoked key with provided hash
+func (s *Storage) GetAPIKeyByHash(ctx context.Context, hash string) (key entity.APIKey, err error) {
+	defer func() {
+		if errors.Is(err, ErrNotFound) {
+			s.l.BadInput(ctx, "postgres: GetAPIKeyByHash", nil, err)
+			return
+		}
+		s.l.Standart(ctx, "postgres: GetAPIKeyByHash", nil, key.ID, err)
+	}()
+	query := `SELECT id, name, role, created_at FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`
+	if err := s.db.QueryRow(ctx, query, hash).Scan(&key.ID, &key.Name, &key.Role, &key.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, fmt.Errorf("active key with provided hash not found: %w", ErrNotFound)
+		}
+		return entity.APIKey{}, fmt.Errorf("failed to select key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int) (err error) {
+	defer func() {
+		if errors.Is(err, ErrNotFound) {
+			s.l.BadInput(ctx, "postgres: RevokeAPIKey", id, err)
+			return
+		}
+		s.l.Standart(ctx, "postgres: RevokeAPIKey", id, nil, err)
+	}()
+	res, err := s.db.Exec(ctx, `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to exec update: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("active key with provided id not found: %w", ErrNotFound)
+	}
+	return nil
+}
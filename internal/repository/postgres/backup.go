@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+// rows of backup, fields are named as columns
+
+type SongRow struct {
+	ID          int       `json:"id"`
+	Group       string    `json:"group"`
+	Title       string    `json:"title"`
+	ReleaseDate time.Time `json:"release_date"`
+	Text        string    `json:"text"`
+	Link        string    `json:"link"`
+	Locked      bool      `json:"locked"`
+	// parsed from Text on restore, not stored in backup
+	Lyrics []entity.Section `json:"-"`
+}
+
+type ProposalRow struct {
+	ID          int        `json:"id"`
+	SongID      int        `json:"song_id"`
+	ReleaseDate *time.Time `json:"release_date"`
+	Text        *string    `json:"text"`
+	Link        *string    `json:"link"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type APIKeyRow struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	KeyHash   string     `json:"key_hash"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// Snapshot reads consistent state of tables in one repeatable read transaction
+type Snapshot struct {
+	tx pgx.Tx
+}
+
+// Snapshot calls fn with read only repeatable read transaction
+func (s *Storage) Snapshot(ctx context.Context, fn func(snap *Snapshot) error) (err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: Snapshot", nil, nil, err)
+	}()
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	//read only, nothing to commit
+	defer tx.Rollback(ctx)
+	return fn(&Snapshot{tx: tx})
+}
+
+func (s *Snapshot) SchemaVersion(ctx context.Context) (version int64, err error) {
+	if err := s.tx.QueryRow(ctx, migrationVersionQuery).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to select schema version: %w", err)
+	}
+	return version, nil
+}
+
+func (s *Snapshot) Songs(ctx context.Context, fn func(row SongRow) error) error {
+	var row SongRow
+	return s.each(ctx, `SELECT id, "group", title, release_date, text, link, locked FROM songs ORDER BY id`,
+		[]any{&row.ID, &row.Group, &row.Title, &row.ReleaseDate, &row.Text, &row.Link, &row.Locked},
+		func() error { return fn(row) })
+}
+
+func (s *Snapshot) Proposals(ctx context.Context, fn func(row ProposalRow) error) error {
+	var row ProposalRow
+	return s.each(ctx, `SELECT id, song_id, release_date, text, link, created_at FROM song_proposals ORDER BY id`,
+		[]any{&row.ID, &row.SongID, &row.ReleaseDate, &row.Text, &row.Link, &row.CreatedAt},
+		func() error { return fn(row) })
+}
+
+func (s *Snapshot) APIKeys(ctx context.Context, fn func(row APIKeyRow) error) error {
+	var row APIKeyRow
+	return s.each(ctx, `SELECT id, name, key_hash, role, created_at, revoked_at FROM api_keys ORDER BY id`,
+		[]any{&row.ID, &row.Name, &row.KeyHash, &row.Role, &row.CreatedAt, &row.RevokedAt},
+		func() error { return fn(row) })
+}
+
+// each scans rows of query into dest and calls fn for each of them
+func (s *Snapshot) each(ctx context.Context, query string, dest []any, fn func() error) error {
+	rows, err := s.tx.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to select: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	if rows.Err() != nil {
+		return fmt.Errorf("error in row: %w", rows.Err())
+	}
+	return nil
+}
+
+// conflict policies of restore
+const (
+	// existing row with the same id or unique key fails restore
+	ConflictFail = "fail"
+	// existing rows are kept, proposals of kept songs are skipped too
+	ConflictSkip = "skip"
+	// existing rows with the same id or unique key (song_id of proposal, key_hash) are replaced by rows of backup
+	ConflictOverwrite = "overwrite"
+	// all tables are emptied before restore
+	ConflictReplace = "replace"
+)
+
+var ErrConflict = errors.New("row already exists")
+
+// sqlstate of unique_violation
+const uniqueViolation = "23505"
+
+// Loader inserts rows of backup in one transaction
+type Loader struct {
+	tx     pgx.Tx
+	policy string
+	// count of inserted or updated rows per table
+	Loaded map[string]int
+	// ids of songs kept instead of rows of backup, their proposals belong to other songs
+	skippedSongs map[int]bool
+}
+
+// Restore calls fn with loader and commits if fn succeeds and dryRun is false
+func (s *Storage) Restore(ctx context.Context, policy string, dryRun bool, fn func(l *Loader) error) (err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: Restore", map[string]interface{}{"policy": policy, "dry_run": dryRun}, nil, err)
+	}()
+	switch policy {
+	case ConflictFail, ConflictSkip, ConflictOverwrite, ConflictReplace:
+	default:
+		return fmt.Errorf("unknown conflict policy %q", policy)
+	}
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	if policy == ConflictReplace {
+		if _, err := tx.Exec(ctx, `TRUNCATE songs, song_proposals, api_keys RESTART IDENTITY CASCADE`); err != nil {
+			return fmt.Errorf("failed to truncate: %w", err)
+		}
+	}
+	loader := &Loader{tx: tx, policy: policy, Loaded: make(map[string]int), skippedSongs: make(map[int]bool)}
+	if err := fn(loader); err != nil {
+		return err
+	}
+	//ids are restored as is, sequences continue after them
+	for _, table := range []string{"songs", "song_proposals", "api_keys"} {
+		query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %[1]s`, table)
+		if _, err := tx.Exec(ctx, query); err != nil {
+			return fmt.Errorf("failed to reset sequence of %s: %w", table, err)
+		}
+	}
+	if dryRun {
+		return nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion is read in restore transaction, so migrations can't change schema after the check
+func (l *Loader) SchemaVersion(ctx context.Context) (version int64, err error) {
+	if err := l.tx.QueryRow(ctx, migrationVersionQuery).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to select schema version: %w", err)
+	}
+	return version, nil
+}
+
+func (l *Loader) Song(ctx context.Context, row SongRow) error {
+	inserted, err := l.insert(ctx, "songs",
+		[]string{"id", `"group"`, "title", "release_date", "text", "link", "locked", "lyrics"},
+		row.ID, row.Group, row.Title, row.ReleaseDate, row.Text, row.Link, row.Locked, lyricsArg(row.Lyrics))
+	if err == nil && !inserted {
+		l.skippedSongs[row.ID] = true
+	}
+	return err
+}
+
+func (l *Loader) Proposal(ctx context.Context, row ProposalRow) error {
+	if l.skippedSongs[row.SongID] {
+		return nil
+	}
+	if err := l.dropDuplicate(ctx, "song_proposals", "song_id", row.SongID, row.ID); err != nil {
+		return err
+	}
+	_, err := l.insert(ctx, "song_proposals",
+		[]string{"id", "song_id", "release_date", "text", "link", "created_at"},
+		row.ID, row.SongID, row.ReleaseDate, row.Text, row.Link, row.CreatedAt)
+	return err
+}
+
+func (l *Loader) APIKey(ctx context.Context, row APIKeyRow) error {
+	if err := l.dropDuplicate(ctx, "api_keys", "key_hash", row.KeyHash, row.ID); err != nil {
+		return err
+	}
+	_, err := l.insert(ctx, "api_keys",
+		[]string{"id", "name", "key_hash", "role", "created_at", "revoked_at"},
+		row.ID, row.Name, row.KeyHash, row.Role, row.CreatedAt, row.RevokedAt)
+	return err
+}
+
+// dropDuplicate deletes row having the same unique key as row of backup under other id, so overwrite
+// doesn't fail on the unique constraint. Does nothing for other policies
+func (l *Loader) dropDuplicate(ctx context.Context, table string, column string, value any, id int) error {
+	if l.policy != ConflictOverwrite {
+		return nil
+	}
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1 AND id <> $2`, table, column)
+	if _, err := l.tx.Exec(ctx, query, value, id); err != nil {
+		return fmt.Errorf("failed to delete duplicate of %s by %s: %w", table, column, err)
+	}
+	return nil
+}
+
+// insert builds insert of row resolving conflict according to policy. Returns false if existing row was kept
+func (l *Loader) insert(ctx context.Context, table string, columns []string, args ...any) (bool, error) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "INSERT INTO %s (%s) VALUES (", table, strings.Join(columns, ", "))
+	for i := range columns {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString("$" + strconv.Itoa(i+1))
+	}
+	buf.WriteString(")")
+	switch l.policy {
+	case ConflictSkip:
+		buf.WriteString(" ON CONFLICT DO NOTHING")
+	case ConflictOverwrite:
+		buf.WriteString(" ON CONFLICT (id) DO UPDATE SET ")
+		for i, column := range columns[1:] {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(column + " = EXCLUDED." + column)
+		}
+	}
+	res, err := l.tx.Exec(ctx, buf.String(), args...)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return false, fmt.Errorf("%s with id %v (%s): %w", table, args[0], pgErr.ConstraintName, ErrConflict)
+		}
+		return false, fmt.Errorf("failed to insert into %s: %w", table, err)
+	}
+	l.Loaded[table] += int(res.RowsAffected())
+	return res.RowsAffected() > 0, nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Config struct {
+	Host     string `env:"POSTGRES_HOST" envDefault:"localhost"`
+	Port     int    `env:"POSTGRES_PORT" envDefault:"5432"`
+	User     string `env:"POSTGRES_USER"`
+	Password string `env:"POSTGRES_PASSWORD"`
+	Name     string `env:"POSTGRES_NAME"`
+
+	// disable, allow, prefer, require, verify-ca, verify-full
+	SSLMode     string `env:"POSTGRES_SSLMODE" envDefault:"prefer"`
+	SSLRootCert string `env:"POSTGRES_SSLROOTCERT"`
+	SSLCert     string `env:"POSTGRES_SSLCERT"`
+	SSLKey      string `env:"POSTGRES_SSLKEY"`
+
+	ApplicationName string `env:"POSTGRES_APPLICATION_NAME" envDefault:"musiclib"`
+	// comma separated schemas, server default if empty
+	SearchPath     string        `env:"POSTGRES_SEARCH_PATH"`
+	ConnectTimeout time.Duration `env:"POSTGRES_CONNECT_TIMEOUT" envDefault:"5s"`
+	// applied to pool connections only, migrations are not limited. 0 disables timeout
+	StatementTimeout time.Duration `env:"POSTGRES_STATEMENT_TIMEOUT"`
+
+	// pool settings, 0 keeps pgxpool defaults
+	MaxConns          int32         `env:"POSTGRES_MAX_CONNS"`
+	MinConns          int32         `env:"POSTGRES_MIN_CONNS"`
+	MaxConnLifetime   time.Duration `env:"POSTGRES_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   time.Duration `env:"POSTGRES_MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod time.Duration `env:"POSTGRES_HEALTH_CHECK_PERIOD"`
+
+	Startup StartupConfig
+	Replica ReplicaConfig
+}
+
+// ConnString returns url with escaped credentials, understood by both pgx and lib/pq
+func (cfg *Config) ConnString() string {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("sslmode", cfg.SSLMode)
+	set("sslrootcert", cfg.SSLRootCert)
+	set("sslcert", cfg.SSLCert)
+	set("sslkey", cfg.SSLKey)
+	set("application_name", cfg.ApplicationName)
+	set("search_path", cfg.SearchPath)
+	if cfg.ConnectTimeout > 0 {
+		//both drivers take seconds, at least one
+		set("connect_timeout", strconv.Itoa(max(int(cfg.ConnectTimeout/time.Second), 1)))
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// poolConfig parses connection string and applies pool settings
+func (cfg *Config) poolConfig() (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.ConnString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	if cfg.StatementTimeout > 0 {
+		poolCfg.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	//span per query
+	poolCfg.ConnConfig.Tracer = &queryTracer{dbName: cfg.Name}
+	return poolCfg, nil
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// WithAdvisoryLock runs fn holding session advisory lock with key, like migrator does, so only one
+// instance runs it at a time. Returns false without running fn if lock is held by another session
+func (s *Storage) WithAdvisoryLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (acquired bool, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: WithAdvisoryLock", key, acquired, err)
+	}()
+	//session lock belongs to connection, so one connection is held until fn returns
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire conn: %w", err)
+	}
+	defer conn.Release()
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		return false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		//ctx may be canceled already, lock must be released anyway
+		if _, unlockErr := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", key); unlockErr != nil {
+			//lock is released with connection
+			conn.Conn().Close(context.Background())
+			err = errors.Join(err, fmt.Errorf("failed to release advisory lock: %w", unlockErr))
+		}
+	}()
+	return true, fn(ctx)
+}
@@ -1,38 +1,224 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"strings"
 
 	_ "github.com/lib/pq" // PostgreSQL driver conn
-	"github.com/pressly/goose"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/migrations"
 )
 
 type MigrationConfig struct {
-	Action         string `env:"GOOSE_MIGRATE"`
-	Driver         string `env:"GOOSE_DRIVER"`
-	ConnStr        string `env:"GOOSE_DBSTRING"`
+	// action on startup: up, down or no
+	Action string `env:"GOOSE_MIGRATE" envDefault:"up"`
+	Driver string `env:"GOOSE_DRIVER" envDefault:"postgres"`
+	// overrides connection built from POSTGRES_* variables
+	ConnStr string `env:"GOOSE_DBSTRING"`
+	// migrations embedded into binary are used if empty
 	MigrationsPath string `env:"GOOSE_MIGRATION_DIR"`
 }
 
-// action - up migrates up, down migrates down, everything else return nil
-func Migrate(cfg *MigrationConfig) error {
+// Source returns migrations from GOOSE_MIGRATION_DIR or embedded ones
+func (cfg *MigrationConfig) Source() fs.FS {
+	if cfg.MigrationsPath != "" {
+		return os.DirFS(cfg.MigrationsPath)
+	}
+	return migrations.FS
+}
+
+// Migrator applies migrations holding postgres advisory lock, so replicas don't migrate concurrently
+type Migrator struct {
+	db       *sql.DB
+	provider *goose.Provider
+	// provider without lock, for steps run under one lock taken by Migrator
+	unlocked *goose.Provider
+	locker   lock.SessionLocker
+	l        *logger.Log
+}
+
+// NewMigrator waits for postgres according to startup config and collects migrations
+func NewMigrator(ctx context.Context, cfg *MigrationConfig, startup StartupConfig, l *logger.Log) (*Migrator, error) {
 	db, err := sql.Open(cfg.Driver, cfg.ConnStr)
 	if err != nil {
-		return fmt.Errorf("failed to open migration conn: %w", err)
+		return nil, fmt.Errorf("failed to open migration conn: %w", err)
+	}
+	if err := waitFor(ctx, startup, l, "migrations", db.PingContext); err != nil {
+		db.Close()
+		return nil, err
+	}
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create migration lock: %w", err)
+	}
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, cfg.Source(), goose.WithSessionLocker(locker))
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	unlocked, err := goose.NewProvider(goose.DialectPostgres, db, cfg.Source())
+	if err != nil {
+		provider.Close()
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	return &Migrator{db: db, provider: provider, unlocked: unlocked, locker: locker, l: l}, nil
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.provider.Up(ctx)
+	m.logResults(results...)
+	if err != nil {
+		return fmt.Errorf("failed to migrate UP: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) UpTo(ctx context.Context, version int64) error {
+	results, err := m.provider.UpTo(ctx, version)
+	m.logResults(results...)
+	if err != nil {
+		return fmt.Errorf("failed to migrate UP to %d: %w", version, err)
+	}
+	return nil
+}
+
+// Down rolls back the last applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	result, err := m.provider.Down(ctx)
+	m.logResults(result)
+	if err != nil {
+		return fmt.Errorf("failed to migrate DOWN: %w", err)
+	}
+	return nil
+}
+
+// DownTo rolls back migrations newer than version, 0 rolls back all
+func (m *Migrator) DownTo(ctx context.Context, version int64) error {
+	results, err := m.provider.DownTo(ctx, version)
+	m.logResults(results...)
+	if err != nil {
+		return fmt.Errorf("failed to migrate DOWN to %d: %w", version, err)
+	}
+	return nil
+}
+
+// Redo rolls back the last applied migration and applies it again. Lock is held for both steps,
+// so other instance can't migrate in between
+func (m *Migrator) Redo(ctx context.Context) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration conn: %w", err)
 	}
-	defer db.Close()
-	switch strings.ToLower(cfg.Action) {
-	case "up":
-		if err := goose.Up(db, cfg.MigrationsPath); err != nil {
-			return fmt.Errorf("failed to migrate UP: %w", err)
+	defer conn.Close()
+	if err := m.locker.SessionLock(ctx, conn); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := m.locker.SessionUnlock(context.Background(), conn); unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release migration lock: %w", unlockErr))
+		}
+	}()
+	result, err := m.unlocked.Down(ctx)
+	m.logResults(result)
+	if err != nil {
+		return fmt.Errorf("failed to migrate DOWN: %w", err)
+	}
+	result, err = m.unlocked.UpByOne(ctx)
+	m.logResults(result)
+	if err != nil {
+		return fmt.Errorf("failed to migrate UP: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migrations status: %w", err)
+	}
+	return statuses, nil
+}
+
+// Version returns version of the last applied migration
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	version, err := m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get migration version: %w", err)
+	}
+	return version, nil
+}
+
+// Close closes db shared by both providers
+func (m *Migrator) Close() {
+	m.provider.Close()
+}
+
+func (m *Migrator) logResults(results ...*goose.MigrationResult) {
+	for _, r := range results {
+		if r == nil || r.Source == nil {
+			continue
+		}
+		if r.Error != nil {
+			m.l.Error("postgres: migration failed", "version", r.Source.Version, "direction", r.Direction, "error", r.Error.Error())
+			continue
 		}
-	case "down":
-		if err := goose.Down(db, cfg.MigrationsPath); err != nil {
-			return fmt.Errorf("failed to migrate UP: %w", err)
+		m.l.Info("postgres: migration applied", "version", r.Source.Version, "direction", r.Direction, "duration", r.Duration.String())
+	}
+}
+
+// Migrate does startup action - up migrates up, down rolls back the last migration, everything else return nil
+func Migrate(ctx context.Context, cfg *MigrationConfig, startup StartupConfig, l *logger.Log) error {
+	action := strings.ToLower(cfg.Action)
+	if action != "up" && action != "down" {
+		return nil
+	}
+	migrator, err := NewMigrator(ctx, cfg, startup, l)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	if action == "down" {
+		return migrator.Down(ctx)
+	}
+	return migrator.Up(ctx)
+}
+
+// LatestMigration returns version of the last migration in source
+func LatestMigration(cfg *MigrationConfig) (int64, error) {
+	sources, err := fs.Glob(cfg.Source(), "*.sql")
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	var latest int64
+	for _, name := range sources {
+		version, err := goose.NumericComponent(name)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration name %s: %w", name, err)
 		}
-	default:
+		latest = max(latest, version)
+	}
+	if latest == 0 {
+		return 0, errors.New("failed to find last migration: no migrations")
+	}
+	return latest, nil
+}
+
+// CreateMigration writes blank sql migration with timestamp version to dir
+func CreateMigration(dir string, name string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create migrations dir: %w", err)
+	}
+	if err := goose.Create(nil, dir, name, "sql"); err != nil {
+		return fmt.Errorf("failed to create migration: %w", err)
 	}
 	return nil
 }
@@ -14,17 +14,11 @@ import (
 	"github.com/Rolan335/Musiclib/internal/logger"
 )
 
-type Config struct {
-	Host     string `env:"POSTGRES_HOST"`
-	Port     int    `env:"POSTGRES_PORT"`
-	User     string `env:"POSTGRES_USER"`
-	Password string `env:"POSTGRES_PASSWORD"`
-	Name     string `env:"POSTGRES_NAME"`
-}
-
 type Storage struct {
 	db *pgxpool.Pool
-	l  *logger.Log
+	// nil if replica is not configured
+	replica *replica
+	l       *logger.Log
 }
 
 type Song struct {
@@ -36,20 +30,33 @@ type Song struct {
 	Link        string
 }
 
-func MustNewStorage(cfg *Config, l *logger.Log) *Storage {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name)
-	conn, err := pgxpool.New(context.Background(), connStr)
+// NewStorage creates pool and waits until postgres accepts connections
+func NewStorage(ctx context.Context, cfg *Config, l *logger.Log) (*Storage, error) {
+	poolCfg, err := cfg.poolConfig()
 	if err != nil {
-		panic("failed to create pool: " + err.Error())
+		return nil, err
 	}
-
-	if err := conn.Ping(context.Background()); err != nil {
-		panic("can't connect to postgres: " + err.Error())
+	conn, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pool: %w", err)
 	}
-	return &Storage{
+	if err := waitFor(ctx, cfg.Startup, l, "pool", conn.Ping); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	storage := &Storage{
 		db: conn,
 		l:  l,
 	}
+	if cfg.Replica.Host != "" {
+		storage.replica, err = newReplica(ctx, cfg)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create replica pool: %w", err)
+		}
+		l.Info("postgres: reads of songs are routed to replica", "addr", cfg.Replica.addr(cfg.Port))
+	}
+	return storage, nil
 }
 
 func (s *Storage) SelectSongs(ctx context.Context, params entity.GetSongsParams) (song []entity.Song, err error) {
@@ -58,7 +65,7 @@ func (s *Storage) SelectSongs(ctx context.Context, params entity.GetSongsParams)
 	}()
 	var buf strings.Builder
 	//initial query
-	buf.WriteString(`SELECT id, "group", title, release_date, text, link FROM songs WHERE 1=1`)
+	buf.WriteString(`SELECT id, "group", title, release_date, text, link, locked FROM songs WHERE 1=1`)
 	args := make([]interface{}, 0, 7) // total count of params is 7, to avoid reallocation
 	//less than 9 params (7) so we can use runes for indexes to concat faster
 	index := '1'
@@ -107,7 +114,19 @@ func (s *Storage) SelectSongs(ctx context.Context, params entity.GetSongsParams)
 		buf.WriteRune(index)
 		args = append(args, *params.PageSize, (*params.Page-1)*(*params.PageSize))
 	}
-	rows, err := s.db.Query(ctx, buf.String(), args...)
+	var songs []entity.Song
+	err = s.read(ctx, "SelectSongs", func(db *pgxpool.Pool) error {
+		songs, err = selectSongs(ctx, db, buf.String(), args)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return songs, nil
+}
+
+func selectSongs(ctx context.Context, db *pgxpool.Pool, query string, args []interface{}) ([]entity.Song, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select: %w", err)
 	}
@@ -115,7 +134,7 @@ func (s *Storage) SelectSongs(ctx context.Context, params entity.GetSongsParams)
 	songs := make([]entity.Song, 0)
 	for rows.Next() {
 		var song entity.Song
-		err := rows.Scan(&song.ID, &song.Group, &song.Title, &song.ReleaseDate, &song.Text, &song.Link)
+		err := rows.Scan(&song.ID, &song.Group, &song.Title, &song.ReleaseDate, &song.Text, &song.Link, &song.Locked)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
@@ -129,12 +148,75 @@ func (s *Storage) SelectSongs(ctx context.Context, params entity.GetSongsParams)
 	return songs, nil
 }
 
+// SelectSongsAfter returns up to limit songs with id greater than afterID, ordered by id.
+// Used to walk the whole table in batches
+func (s *Storage) SelectSongsAfter(ctx context.Context, afterID int, limit int) (songs []entity.Song, err error) {
+	defer func() {
+		params := map[string]int{
+			"afterID": afterID,
+			"limit":   limit,
+		}
+		s.l.Standart(ctx, "postgres: SelectSongsAfter", params, len(songs), err)
+	}()
+	query := `SELECT id, "group", title, release_date, text, link, locked FROM songs WHERE id > $1 ORDER BY id ASC LIMIT $2`
+	rows, err := s.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	defer rows.Close()
+	songs = make([]entity.Song, 0, limit)
+	for rows.Next() {
+		var song entity.Song
+		err := rows.Scan(&song.ID, &song.Group, &song.Title, &song.ReleaseDate, &song.Text, &song.Link, &song.Locked)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		songs = append(songs, song)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error in row: %w", rows.Err())
+	}
+
+	return songs, nil
+}
+
+// SelectSongsWithoutLyrics returns up to limit songs with id greater than afterID and without parsed lyrics,
+// only id and text are selected
+func (s *Storage) SelectSongsWithoutLyrics(ctx context.Context, afterID int, limit int) (songs []entity.Song, err error) {
+	defer func() {
+		params := map[string]int{
+			"afterID": afterID,
+			"limit":   limit,
+		}
+		s.l.Standart(ctx, "postgres: SelectSongsWithoutLyrics", params, len(songs), err)
+	}()
+	query := `SELECT id, text FROM songs WHERE id > $1 AND lyrics IS NULL ORDER BY id ASC LIMIT $2`
+	rows, err := s.db.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	defer rows.Close()
+	songs = make([]entity.Song, 0, limit)
+	for rows.Next() {
+		var song entity.Song
+		if err := rows.Scan(&song.ID, &song.Text); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		songs = append(songs, song)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error in row: %w", rows.Err())
+	}
+	return songs, nil
+}
+
 func (s *Storage) CreateSong(ctx context.Context, song entity.Song) (ID int, err error) {
 	defer func() {
 		s.l.Standart(ctx, "postgres: CreateSong", song, ID, err)
 	}()
-	query := `INSERT INTO songs ("group", title, release_date, text, link) VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	if err := s.db.QueryRow(ctx, query, song.Group, song.Title, song.ReleaseDate, song.Text, song.Link).
+	query := `INSERT INTO songs ("group", title, release_date, text, link, lyrics) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := s.db.QueryRow(ctx, query, song.Group, song.Title, song.ReleaseDate, song.Text, song.Link, lyricsArg(song.Lyrics)).
 		Scan(&ID); err != nil {
 		return 0, fmt.Errorf("failed to exec insert: %w", err)
 	}
@@ -180,12 +262,39 @@ func (s *Storage) UpdateSong(ctx context.Context, id int, song entity.SongNullab
 		}
 		return fmt.Errorf("failed to select song id: %w", err)
 	}
+	query, args := updateSongQuery(id, song, "")
+	if _, err := s.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateUnlockedSong updates song if it isn't locked, false means song is locked or deleted.
+// Lock is checked by the same statement, so song locked after it was read isn't overwritten
+func (s *Storage) UpdateUnlockedSong(ctx context.Context, id int, song entity.SongNullable) (updated bool, err error) {
+	defer func() {
+		params := map[string]interface{}{
+			"id":   id,
+			"song": s.l.FormatSongNullable(song),
+		}
+		s.l.Standart(ctx, "postgres: UpdateUnlockedSong", params, updated, err)
+	}()
+	query, args := updateSongQuery(id, song, " AND NOT locked")
+	tag, err := s.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to update: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
 
+// updateSongQuery builds update of provided fields, cond is added to condition by id
+func updateSongQuery(id int, song entity.SongNullable, cond string) (string, []interface{}) {
 	var buf strings.Builder
 	//initial query
 	buf.WriteString(`UPDATE songs SET`)
-	args := make([]interface{}, 0, 5) // total count of params is 5, to avoid reallocation
-	//less than 9 params (5) so we can use runes for indexes to concat faster
+	args := make([]interface{}, 0, 9) // total count of params is 9, to avoid reallocation
+	//not more than 9 params so we can use runes for indexes to concat faster
 	index := '1'
 	comma := ','
 
@@ -219,6 +328,12 @@ func (s *Storage) UpdateSong(ctx context.Context, id int, song entity.SongNullab
 		buf.WriteRune(comma)
 		index++
 		args = append(args, *song.Text)
+		//sections are replaced with text, NULL is parsed on read
+		buf.WriteString(" lyrics = $")
+		buf.WriteRune(index)
+		buf.WriteRune(comma)
+		index++
+		args = append(args, lyricsArg(song.Lyrics))
 	}
 
 	if song.Link != nil {
@@ -229,17 +344,19 @@ func (s *Storage) UpdateSong(ctx context.Context, id int, song entity.SongNullab
 		args = append(args, *song.Link)
 	}
 
+	if song.Locked != nil {
+		buf.WriteString(" locked = $")
+		buf.WriteRune(index)
+		buf.WriteRune(comma)
+		index++
+		args = append(args, *song.Locked)
+	}
+
 	//delete last comma and add id
 	query := buf.String()
-	query = query[:len(query)-1] + " WHERE id = $" + string(index)
+	query = query[:len(query)-1] + " WHERE id = $" + string(index) + cond
 	args = append(args, id)
-	fmt.Println(query)
-	fmt.Println(args...)
-	if _, err := s.db.Exec(ctx, query, args...); err != nil {
-		return fmt.Errorf("failed to update: %w", err)
-	}
-
-	return nil
+	return query, args
 }
 
 func (s *Storage) GetSong(ctx context.Context, id int) (song entity.Song, err error) {
@@ -250,16 +367,65 @@ func (s *Storage) GetSong(ctx context.Context, id int) (song entity.Song, err er
 		}
 		s.l.Standart(ctx, "postgres: GetSong", id, song, err)
 	}()
-	query := `SELECT "group", title, release_date, text, link FROM songs WHERE id = $1 LIMIT 1`
-	if err := s.db.QueryRow(ctx, query, id).Scan(&song.Group, &song.Title, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return entity.Song{}, fmt.Errorf("data with provided id not found: %w", ErrNotFound)
+	query := `SELECT id, "group", title, release_date, text, link, locked, lyrics FROM songs WHERE id = $1 LIMIT 1`
+	err = s.read(ctx, "GetSong", func(db *pgxpool.Pool) error {
+		if err := db.QueryRow(ctx, query, id).Scan(&song.ID, &song.Group, &song.Title, &song.ReleaseDate, &song.Text, &song.Link, &song.Locked, &song.Lyrics); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("data with provided id not found: %w", ErrNotFound)
+			}
+			return fmt.Errorf("failed to select song: %w", err)
 		}
-		return entity.Song{}, fmt.Errorf("failed to select song: %w", err)
+		return nil
+	})
+	if err != nil {
+		return entity.Song{}, err
 	}
 	return song, nil
 }
 
 func (s *Storage) Close() {
 	s.db.Close()
+	if s.replica != nil {
+		s.replica.pool.Close()
+	}
+}
+
+// CopySongs loads songs returned by next until it returns false, using COPY protocol. Parsed lyrics are stored too
+func (s *Storage) CopySongs(ctx context.Context, next func() (entity.Song, bool)) (count int64, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: CopySongs", nil, count, err)
+	}()
+	columns := []string{"group", "title", "release_date", "text", "link", "lyrics"}
+	source := pgx.CopyFromFunc(func() ([]any, error) {
+		song, ok := next()
+		if !ok {
+			return nil, nil
+		}
+		return []any{song.Group, song.Title, song.ReleaseDate, song.Text, song.Link, lyricsArg(song.Lyrics)}, nil
+	})
+	count, err = s.db.CopyFrom(ctx, pgx.Identifier{"songs"}, columns, source)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy songs: %w", err)
+	}
+	return count, nil
+}
+
+// UpdateLyrics stores sections parsed from current text of song
+func (s *Storage) UpdateLyrics(ctx context.Context, id int, text string, lyrics []entity.Section) (err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: UpdateLyrics", id, len(lyrics), err)
+	}()
+	//text is compared, so sections of changed text are not stored
+	if _, err := s.db.Exec(ctx, `UPDATE songs SET lyrics = $1 WHERE id = $2 AND text = $3`, lyricsArg(lyrics), id, text); err != nil {
+		return fmt.Errorf("failed to update lyrics: %w", err)
+	}
+	return nil
+}
+
+// lyricsArg converts nil sections to NULL instead of json null
+func lyricsArg(lyrics []entity.Section) any {
+	if lyrics == nil {
+		return nil
+	}
+	return lyrics
 }
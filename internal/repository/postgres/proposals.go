@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+// UpsertProposal saves proposal for the song. Song has at most one proposal, newer replaces older
+func (s *Storage) UpsertProposal(ctx context.Context, proposal entity.Proposal) (ID int, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: UpsertProposal", proposal, ID, err)
+	}()
+	query := `INSERT INTO song_proposals (song_id, release_date, text, link) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (song_id) DO UPDATE SET release_date = $2, text = $3, link = $4, created_at = now()
+	RETURNING id`
+	if err := s.db.QueryRow(ctx, query, proposal.SongID, proposal.ReleaseDate, proposal.Text, proposal.Link).
+		Scan(&ID); err != nil {
+		return 0, fmt.Errorf("failed to exec upsert: %w", err)
+	}
+	return ID, nil
+}
+
+func (s *Storage) SelectProposals(ctx context.Context) (proposals []entity.Proposal, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: SelectProposals", nil, proposals, err)
+	}()
+	query := `SELECT id, song_id, release_date, text, link, created_at FROM song_proposals ORDER BY id ASC`
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	defer rows.Close()
+	proposals = make([]entity.Proposal, 0)
+	for rows.Next() {
+		var p entity.Proposal
+		if err := rows.Scan(&p.ID, &p.SongID, &p.ReleaseDate, &p.Text, &p.Link, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		proposals = append(proposals, p)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("error in row: %w", rows.Err())
+	}
+
+	return proposals, nil
+}
+
+func (s *Storage) GetProposal(ctx context.Context, id int) (proposal entity.Proposal, err error) {
+	defer func() {
+		if errors.Is(err, ErrNotFound) {
+			s.l.BadInput(ctx, "postgres: GetProposal", id, err)
+			return
+		}
+		s.l.Standart(ctx, "postgres: GetProposal", id, proposal, err)
+	}()
+	query := `SELECT id, song_id, release_date, text, link, created_at FROM song_proposals WHERE id = $1`
+	if err := s.db.QueryRow(ctx, query, id).
+		Scan(&proposal.ID, &proposal.SongID, &proposal.ReleaseDate, &proposal.Text, &proposal.Link, &proposal.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Proposal{}, fmt.Errorf("data with provided id not found: %w", ErrNotFound)
+		}
+		return entity.Proposal{}, fmt.Errorf("failed to select proposal: %w", err)
+	}
+	return proposal, nil
+}
+
+func (s *Storage) DeleteProposal(ctx context.Context, id int) (err error) {
+	defer func() {
+		if errors.Is(err, ErrNotFound) {
+			s.l.BadInput(ctx, "postgres: DeleteProposal", id, err)
+			return
+		}
+		s.l.Standart(ctx, "postgres: DeleteProposal", id, nil, err)
+	}()
+	res, err := s.db.Exec(ctx, `DELETE FROM song_proposals WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to exec delete: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("data with provided id not found: %w", ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IncrementQuota increments usage of the client for the day and returns usage after increment
+func (s *Storage) IncrementQuota(ctx context.Context, client string, class string, day time.Time) (count int, err error) {
+	defer func() {
+		params := map[string]string{
+			"client": client,
+			"class":  class,
+			"day":    day.Format(time.DateOnly),
+		}
+		s.l.Standart(ctx, "postgres: IncrementQuota", params, count, err)
+	}()
+	query := `INSERT INTO quota_usage (client, class, day, count) VALUES ($1, $2, $3, 1)
+	ON CONFLICT (client, class, day) DO UPDATE SET count = quota_usage.count + 1
+	RETURNING count`
+	if err := s.db.QueryRow(ctx, query, client, class, day).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to exec upsert: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteQuotasBefore removes usage of days before day
+func (s *Storage) DeleteQuotasBefore(ctx context.Context, day time.Time) (deleted int64, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: DeleteQuotasBefore", day.Format(time.DateOnly), deleted, err)
+	}()
+	res, err := s.db.Exec(ctx, `DELETE FROM quota_usage WHERE day < $1`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec delete: %w", err)
+	}
+	return res.RowsAffected(), nil
+}
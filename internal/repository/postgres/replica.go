@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReplicaConfig of read replica. Other connection settings are the same as of primary
+type ReplicaConfig struct {
+	// replica is not used if empty
+	Host string `env:"POSTGRES_REPLICA_HOST"`
+	// port of primary if 0
+	Port int `env:"POSTGRES_REPLICA_PORT"`
+	// reads of the caller go to primary during this period after its own write of songs,
+	// applied by handlers with WithPrimary
+	StickyPeriod time.Duration `env:"POSTGRES_REPLICA_STICKY_PERIOD" envDefault:"1s"`
+}
+
+type primaryKey struct{}
+
+// WithPrimary makes reads with returned context go to primary, for reading own writes
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func primaryRequested(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// replica is optional pool for reads of songs
+type replica struct {
+	pool *pgxpool.Pool
+}
+
+func newReplica(ctx context.Context, cfg *Config) (*replica, error) {
+	replicaCfg := *cfg
+	replicaCfg.Host = cfg.Replica.Host
+	if cfg.Replica.Port != 0 {
+		replicaCfg.Port = cfg.Replica.Port
+	}
+	poolCfg, err := replicaCfg.poolConfig()
+	if err != nil {
+		return nil, err
+	}
+	//pool connects lazily, unavailable replica doesn't block start
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &replica{pool: pool}, nil
+}
+
+func (cfg *ReplicaConfig) addr(fallbackPort int) string {
+	port := cfg.Port
+	if port == 0 {
+		port = fallbackPort
+	}
+	return net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+}
+
+// read runs query on replica if it is configured and allowed for ctx, falling back to primary on error
+func (s *Storage) read(ctx context.Context, what string, query func(db *pgxpool.Pool) error) error {
+	if s.replica == nil || primaryRequested(ctx) {
+		return query(s.db)
+	}
+	err := query(s.replica.pool)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	//not found may be caused by replication lag
+	if errors.Is(err, ErrNotFound) {
+		s.l.Debug("postgres: not found on replica, reading primary", "query", what)
+	} else {
+		s.l.Warn("postgres: replica read failed, reading primary", "query", what, "error", err.Error())
+	}
+	return query(s.db)
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+// pools are lazy, queries never reach postgres, pools are told apart by pointer
+func newLazyPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgres://musiclib@127.0.0.1:1/musiclib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestRead(t *testing.T) {
+	errDown := errors.New("connection refused")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		noReplica  bool
+		replicaErr error
+		want       []string
+		wantErr    error
+	}{
+		{name: "replica", ctx: context.Background(), want: []string{"replica"}},
+		{name: "replica fails", ctx: context.Background(), replicaErr: errDown, want: []string{"replica", "primary"}},
+		{name: "not found on lagging replica", ctx: context.Background(), replicaErr: ErrNotFound, want: []string{"replica", "primary"}},
+		{name: "primary requested", ctx: WithPrimary(context.Background()), want: []string{"primary"}},
+		{name: "replica not configured", ctx: context.Background(), noReplica: true, want: []string{"primary"}},
+		{name: "canceled", ctx: canceled, replicaErr: context.Canceled, want: []string{"replica"}, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Storage{db: newLazyPool(t), l: logger.New("error", io.Discard)}
+			if !tt.noReplica {
+				s.replica = &replica{pool: newLazyPool(t)}
+			}
+			var got []string
+			err := s.read(tt.ctx, "Test", func(db *pgxpool.Pool) error {
+				if db == s.db {
+					got = append(got, "primary")
+					return nil
+				}
+				got = append(got, "replica")
+				return tt.replicaErr
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("queried %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
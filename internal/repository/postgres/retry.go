@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+// ErrUnavailable is returned when postgres doesn't accept connections until startup deadline
+var ErrUnavailable = errors.New("postgres is unavailable")
+
+// StartupConfig bounds waiting for postgres on start, e.g. when containers start together
+type StartupConfig struct {
+	Timeout time.Duration `env:"POSTGRES_STARTUP_TIMEOUT" envDefault:"60s"`
+	// first delay between attempts, doubled up to MaxBackoff
+	Backoff    time.Duration `env:"POSTGRES_STARTUP_BACKOFF" envDefault:"500ms"`
+	MaxBackoff time.Duration `env:"POSTGRES_STARTUP_MAX_BACKOFF" envDefault:"5s"`
+}
+
+// waitFor calls connect until it succeeds, ctx is done or timeout is exceeded
+func waitFor(ctx context.Context, cfg StartupConfig, l *logger.Log, what string, connect func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	backoff := cfg.Backoff
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			if attempt > 1 {
+				l.Info("postgres: connected", "for", what, "attempts", attempt)
+			}
+			return nil
+		}
+		l.Warn("postgres: not available, retrying", "for", what, "attempt", attempt, "retry_in", backoff.String(), "error", err.Error())
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w for %s after %d attempts: %w", ErrUnavailable, what, attempt, err)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, cfg.MaxBackoff)
+	}
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Stat returns statistics of connection pool
+func (s *Storage) Stat() *pgxpool.Stat {
+	return s.db.Stat()
+}
+
+// CountSongs returns total count of songs
+func (s *Storage) CountSongs(ctx context.Context) (count int, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: CountSongs", nil, count, err)
+	}()
+	if err := s.db.QueryRow(ctx, `SELECT count(*) FROM songs`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to exec select: %w", err)
+	}
+	return count, nil
+}
+
+// Ping checks connection to postgres
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
+// the last row of each version tells whether it's applied or rolled back
+const migrationVersionQuery = `SELECT COALESCE(MAX(version_id), 0) FROM (
+	SELECT DISTINCT ON (version_id) version_id, is_applied FROM goose_db_version ORDER BY version_id, id DESC
+) versions WHERE is_applied`
+
+// MigrationVersion returns version of the last applied migration from goose table
+func (s *Storage) MigrationVersion(ctx context.Context) (version int64, err error) {
+	defer func() {
+		s.l.Standart(ctx, "postgres: MigrationVersion", nil, version, err)
+	}()
+	if err := s.db.QueryRow(ctx, migrationVersionQuery).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to exec select: %w", err)
+	}
+	return version, nil
+}
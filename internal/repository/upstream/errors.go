@@ -0,0 +1,7 @@
+package upstream
+
+import "errors"
+
+var ErrNotFound = errors.New("song not found in external api")
+var ErrUnavailable = errors.New("external api unavailable")
+var ErrContractViolation = errors.New("external api contract violated")
@@ -0,0 +1,77 @@
+// Adapter for external music info api
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/tracing"
+	"github.com/Rolan335/Musiclib/pkg/musicinfo"
+)
+
+// layout of releaseDate in external api responses
+const dateLayout = "02.01.2006"
+
+var tracer = otel.Tracer("github.com/Rolan335/Musiclib/internal/repository/upstream")
+
+type Client struct {
+	client    musicinfo.ClientInterface
+	validator *validator
+}
+
+func New(client musicinfo.ClientInterface, cfg Config) (*Client, error) {
+	validator, err := newValidator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		client:    client,
+		validator: validator,
+	}, nil
+}
+
+// GetSongDetail requests details of the song from external api.
+// Response is validated against SongDetail schema, violations are returned as *ContractError.
+// Returned song has group and title from arguments, external api can't overwrite them
+func (c *Client) GetSongDetail(ctx context.Context, group string, title string) (song entity.Song, err error) {
+	ctx, span := tracer.Start(ctx, "upstream.GetSongDetail", trace.WithAttributes(
+		attribute.String("song.group", group),
+		attribute.String("song.title", title),
+	))
+	defer func() {
+		tracing.End(span, err)
+	}()
+	resp, err := c.client.GetInfo(ctx, &musicinfo.GetInfoParams{Group: group, Song: title})
+	if err != nil {
+		return entity.Song{}, fmt.Errorf("failed to request info: %w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return entity.Song{}, fmt.Errorf("external api responded with status %d: %w", resp.StatusCode, ErrUnavailable)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return entity.Song{}, fmt.Errorf("external api responded with status %d: %w", resp.StatusCode, ErrNotFound)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return entity.Song{}, fmt.Errorf("failed to read body: %w: %w", ErrUnavailable, err)
+	}
+	detail, releaseDate, err := c.validator.validate(body)
+	if err != nil {
+		return entity.Song{}, err
+	}
+	return entity.Song{
+		Group:       group,
+		Title:       title,
+		ReleaseDate: releaseDate,
+		Text:        detail.Text,
+		Link:        detail.Link,
+	}, nil
+}
@@ -0,0 +1,173 @@
+package upstream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/Rolan335/Musiclib/pkg/musicinfo"
+)
+
+const (
+	// response with violation of the field is rejected
+	ActionReject = "reject"
+	// violating field is replaced with default value
+	ActionDefault = "default"
+)
+
+// fields of SongDetail
+const (
+	FieldReleaseDate = "releaseDate"
+	FieldText        = "text"
+	FieldLink        = "link"
+)
+
+type Config struct {
+	// action per field on contract violation, e.g. "releaseDate=reject,text=reject,link=default".
+	// Fields without rule are rejected
+	Rules              map[string]string `env:"UPSTREAM_RULES" envKeyValSeparator:"="`
+	DefaultReleaseDate string            `env:"UPSTREAM_DEFAULT_RELEASE_DATE"`
+	DefaultText        string            `env:"UPSTREAM_DEFAULT_TEXT"`
+	DefaultLink        string            `env:"UPSTREAM_DEFAULT_LINK"`
+}
+
+// Violation of the external api contract in one field
+type Violation struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ContractError is returned when external api response violates its OpenAPI definition
+type ContractError struct {
+	Violations []Violation
+}
+
+func (e *ContractError) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, v.Field+": "+v.Reason)
+	}
+	return "external api contract violated: " + strings.Join(reasons, "; ")
+}
+
+func (e *ContractError) Unwrap() error {
+	return ErrContractViolation
+}
+
+// validator checks SongDetail against schema from api/external/musicinfo.yaml and applies rules
+type validator struct {
+	schema   *openapi3.Schema
+	rules    map[string]string
+	defaults map[string]string
+}
+
+func newValidator(cfg Config) (*validator, error) {
+	swagger, err := musicinfo.GetSwagger()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load external api spec: %w", err)
+	}
+	ref, ok := swagger.Components.Schemas["SongDetail"]
+	if !ok || ref.Value == nil {
+		return nil, errors.New("external api spec has no SongDetail schema")
+	}
+	rules := make(map[string]string, len(cfg.Rules))
+	for field, action := range cfg.Rules {
+		action = strings.ToLower(strings.TrimSpace(action))
+		if action != ActionReject && action != ActionDefault {
+			return nil, fmt.Errorf("unknown action %q for field %q", action, field)
+		}
+		rules[strings.TrimSpace(field)] = action
+	}
+	if cfg.DefaultReleaseDate != "" {
+		if _, err := time.Parse(dateLayout, cfg.DefaultReleaseDate); err != nil {
+			return nil, fmt.Errorf("default release date should match %s: %w", dateLayout, err)
+		}
+	} else if rules[FieldReleaseDate] == ActionDefault {
+		return nil, errors.New("default release date is required when releaseDate violations are defaulted")
+	}
+	return &validator{
+		schema: ref.Value,
+		rules:  rules,
+		defaults: map[string]string{
+			FieldReleaseDate: cfg.DefaultReleaseDate,
+			FieldText:        cfg.DefaultText,
+			FieldLink:        cfg.DefaultLink,
+		},
+	}, nil
+}
+
+// validate parses body into SongDetail. Violating fields are defaulted or collected into ContractError
+func (v *validator) validate(body []byte) (musicinfo.SongDetail, time.Time, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return musicinfo.SongDetail{}, time.Time{}, &ContractError{
+			Violations: []Violation{{Field: "", Reason: "body is not a json object: " + err.Error()}},
+		}
+	}
+
+	violations := make(map[string]string)
+	if err := v.schema.VisitJSON(raw, openapi3.MultiErrors()); err != nil {
+		var multi openapi3.MultiError
+		if !errors.As(err, &multi) {
+			multi = openapi3.MultiError{err}
+		}
+		for _, e := range multi {
+			var schemaErr *openapi3.SchemaError
+			if !errors.As(e, &schemaErr) {
+				violations[""] = e.Error()
+				continue
+			}
+			field := ""
+			if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+				field = pointer[0]
+			}
+			violations[field] = schemaErr.Reason
+		}
+	}
+
+	detail := musicinfo.SongDetail{}
+	detail.ReleaseDate, _ = raw[FieldReleaseDate].(string)
+	detail.Text, _ = raw[FieldText].(string)
+	detail.Link, _ = raw[FieldLink].(string)
+
+	//checks not expressible in schema
+	if _, ok := violations[FieldText]; !ok && strings.TrimSpace(detail.Text) == "" {
+		violations[FieldText] = "text is empty"
+	}
+	if _, ok := violations[FieldLink]; !ok && strings.TrimSpace(detail.Link) == "" {
+		violations[FieldLink] = "link is empty"
+	}
+	releaseDate, err := time.Parse(dateLayout, detail.ReleaseDate)
+	if _, ok := violations[FieldReleaseDate]; !ok && err != nil {
+		violations[FieldReleaseDate] = fmt.Sprintf("value %q doesn't match format DD.MM.YYYY", detail.ReleaseDate)
+	}
+
+	rejected := make([]Violation, 0)
+	for _, field := range []string{"", FieldReleaseDate, FieldText, FieldLink} {
+		reason, ok := violations[field]
+		if !ok {
+			continue
+		}
+		if field == "" || v.rules[field] != ActionDefault {
+			rejected = append(rejected, Violation{Field: field, Reason: reason})
+			continue
+		}
+		switch field {
+		case FieldReleaseDate:
+			detail.ReleaseDate = v.defaults[field]
+			releaseDate, _ = time.Parse(dateLayout, detail.ReleaseDate)
+		case FieldText:
+			detail.Text = v.defaults[field]
+		case FieldLink:
+			detail.Link = v.defaults[field]
+		}
+	}
+	if len(rejected) > 0 {
+		return musicinfo.SongDetail{}, time.Time{}, &ContractError{Violations: rejected}
+	}
+	return detail, releaseDate, nil
+}
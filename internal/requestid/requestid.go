@@ -0,0 +1,61 @@
+// Id of the request, accepted from caller or generated, for correlation of logs
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+const Header = "X-Request-ID"
+
+// ids of callers longer than this are replaced with generated
+const maxLength = 128
+
+type key struct{}
+
+// Middleware takes id from X-Request-ID header or generates it, returns it in response header
+// and places it on request context, so every log record of the request has request_id.
+// Should be registered first
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = generate()
+		}
+		c.Header(Header, id)
+		ctx := context.WithValue(c.Request.Context(), key{}, id)
+		ctx = logger.WithAttrs(ctx, slog.String("request_id", id))
+		c.Request = c.Request.WithContext(ctx)
+	}
+}
+
+// FromContext returns id of the request, empty if there is no one
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+// valid allows visible ascii only, so id can't break log lines or headers
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func generate() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,239 @@
+// Periodic re-sync of song metadata from external api
+package resync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/lyrics"
+	"github.com/Rolan335/Musiclib/internal/repository/upstream"
+)
+
+const (
+	// changes are written to songs immediately
+	ModeApply = "apply"
+	// changes are saved as proposals for review
+	ModePropose = "propose"
+)
+
+// key of postgres advisory lock held during pass, so only one instance resyncs at a time
+const lockKey int64 = 7236151430208412005
+
+// song was locked after it was read
+var errLocked = errors.New("song is locked")
+
+type Config struct {
+	Enabled   bool          `env:"RESYNC_ENABLED"`
+	Interval  time.Duration `env:"RESYNC_INTERVAL" envDefault:"24h"`
+	BatchSize int           `env:"RESYNC_BATCH_SIZE" envDefault:"100"`
+	Mode      string        `env:"RESYNC_MODE" envDefault:"propose"`
+	Timeout   time.Duration `env:"RESYNC_REQUEST_TIMEOUT" envDefault:"5s"`
+}
+
+type Storage interface {
+	SelectSongsAfter(ctx context.Context, afterID int, limit int) ([]entity.Song, error)
+	UpdateUnlockedSong(ctx context.Context, id int, song entity.SongNullable) (bool, error)
+	UpsertProposal(ctx context.Context, proposal entity.Proposal) (int, error)
+	WithAdvisoryLock(ctx context.Context, key int64, fn func(ctx context.Context) error) (bool, error)
+}
+
+type Upstream interface {
+	GetSongDetail(ctx context.Context, group string, title string) (entity.Song, error)
+}
+
+type Refresher struct {
+	storage  Storage
+	upstream Upstream
+	cfg      Config
+	log      *logger.Log
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Stats of one pass over songs
+type Stats struct {
+	Checked  int `json:"checked"`
+	Skipped  int `json:"skipped"`
+	Changed  int `json:"changed"`
+	Failed   int `json:"failed"`
+	NotFound int `json:"notFound"`
+}
+
+func NewRefresher(storage Storage, upstream Upstream, cfg Config, l *logger.Log) *Refresher {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	cfg.Mode = strings.ToLower(cfg.Mode)
+	if cfg.Mode != ModeApply {
+		cfg.Mode = ModePropose
+	}
+	return &Refresher{
+		storage:  storage,
+		upstream: upstream,
+		cfg:      cfg,
+		log:      l,
+	}
+}
+
+// Start runs resync at start and then every Interval in background. Does nothing if resync is disabled
+func (r *Refresher) Start() {
+	if !r.cfg.Enabled || r.cfg.Interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		//first pass right after start, not one interval later
+		r.pass(ctx)
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.pass(ctx)
+			}
+		}
+	}()
+}
+
+func (r *Refresher) pass(ctx context.Context) {
+	if _, err := r.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		r.log.Error("resync: pass failed", "error", err.Error())
+	}
+}
+
+// Close stops background resync and waits for current pass to finish
+func (r *Refresher) Close() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+}
+
+// Run walks all songs in batches and refreshes them from external api.
+// Pass is skipped if another instance is running it
+func (r *Refresher) Run(ctx context.Context) (stats Stats, err error) {
+	acquired, err := r.storage.WithAdvisoryLock(ctx, lockKey, func(ctx context.Context) error {
+		stats, err = r.run(ctx)
+		return err
+	})
+	if err == nil && !acquired {
+		r.log.Info("resync: pass skipped, another instance is running it")
+	}
+	return stats, err
+}
+
+func (r *Refresher) run(ctx context.Context) (stats Stats, err error) {
+	start := time.Now()
+	defer func() {
+		r.log.Info("resync: pass finished",
+			"mode", r.cfg.Mode,
+			"stats", stats,
+			"duration", time.Since(start).String(),
+		)
+	}()
+	lastID := 0
+	for {
+		songs, err := r.storage.SelectSongsAfter(ctx, lastID, r.cfg.BatchSize)
+		if err != nil {
+			return stats, fmt.Errorf("failed to select batch after id %d: %w", lastID, err)
+		}
+		for _, song := range songs {
+			if err := ctx.Err(); err != nil {
+				return stats, err
+			}
+			lastID = song.ID
+			stats.Checked++
+			if song.Locked {
+				stats.Skipped++
+				continue
+			}
+			changed, err := r.refreshSong(ctx, song)
+			switch {
+			case errors.Is(err, upstream.ErrNotFound):
+				stats.NotFound++
+			case errors.Is(err, errLocked):
+				stats.Skipped++
+			case err != nil:
+				stats.Failed++
+				r.log.Warn("resync: failed to refresh song", "id", song.ID, "error", err.Error())
+			case changed:
+				stats.Changed++
+			}
+		}
+		if len(songs) < r.cfg.BatchSize {
+			return stats, nil
+		}
+	}
+}
+
+// refreshSong returns true if song differs from external api
+func (r *Refresher) refreshSong(ctx context.Context, song entity.Song) (bool, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+	fresh, err := r.upstream.GetSongDetail(reqCtx, song.Group, song.Title)
+	if err != nil {
+		return false, err
+	}
+	changes, changed := diff(song, fresh)
+	if !changed {
+		return false, nil
+	}
+	if r.cfg.Mode == ModeApply {
+		if changes.Text != nil {
+			changes.Lyrics = lyrics.Parse(*changes.Text)
+		}
+		updated, err := r.storage.UpdateUnlockedSong(ctx, song.ID, changes)
+		if err != nil {
+			return false, fmt.Errorf("failed to update song: %w", err)
+		}
+		if !updated {
+			return false, errLocked
+		}
+		return true, nil
+	}
+	_, err = r.storage.UpsertProposal(ctx, entity.Proposal{
+		SongID:      song.ID,
+		ReleaseDate: changes.ReleaseDate,
+		Text:        changes.Text,
+		Link:        changes.Link,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to save proposal: %w", err)
+	}
+	return true, nil
+}
+
+// diff returns fields of fresh that differ from current. Only fields provided by external api are compared
+func diff(current entity.Song, fresh entity.Song) (entity.SongNullable, bool) {
+	var changes entity.SongNullable
+	changed := false
+	if !current.ReleaseDate.Equal(fresh.ReleaseDate) {
+		changes.ReleaseDate = &fresh.ReleaseDate
+		changed = true
+	}
+	if current.Text != fresh.Text {
+		changes.Text = &fresh.Text
+		changed = true
+	}
+	if current.Link != fresh.Link {
+		changes.Link = &fresh.Link
+		changed = true
+	}
+	return changes, changed
+}
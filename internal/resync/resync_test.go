@@ -0,0 +1,111 @@
+package resync
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/repository/upstream"
+)
+
+// fakeStorage locks songs by id, lockHeld emulates another instance running resync
+type fakeStorage struct {
+	songs    []entity.Song
+	lockedAt map[int]bool
+	lockHeld bool
+	updated  []int
+}
+
+func (s *fakeStorage) SelectSongsAfter(_ context.Context, afterID int, limit int) ([]entity.Song, error) {
+	batch := make([]entity.Song, 0, limit)
+	for _, song := range s.songs {
+		if song.ID > afterID && len(batch) < limit {
+			batch = append(batch, song)
+		}
+	}
+	return batch, nil
+}
+
+func (s *fakeStorage) UpdateUnlockedSong(_ context.Context, id int, _ entity.SongNullable) (bool, error) {
+	if s.lockedAt[id] {
+		return false, nil
+	}
+	s.updated = append(s.updated, id)
+	return true, nil
+}
+
+func (s *fakeStorage) UpsertProposal(context.Context, entity.Proposal) (int, error) {
+	return 1, nil
+}
+
+func (s *fakeStorage) WithAdvisoryLock(ctx context.Context, _ int64, fn func(ctx context.Context) error) (bool, error) {
+	if s.lockHeld {
+		return false, nil
+	}
+	return true, fn(ctx)
+}
+
+type fakeUpstream map[string]entity.Song
+
+func (u fakeUpstream) GetSongDetail(_ context.Context, group string, title string) (entity.Song, error) {
+	song, ok := u[group+"/"+title]
+	if !ok {
+		return entity.Song{}, upstream.ErrNotFound
+	}
+	return song, nil
+}
+
+func TestRunApply(t *testing.T) {
+	date := time.Date(2006, time.July, 16, 0, 0, 0, 0, time.UTC)
+	songs := []entity.Song{
+		{ID: 1, Group: "Muse", Title: "Changed", ReleaseDate: date, Text: "old"},
+		{ID: 2, Group: "Muse", Title: "Same", ReleaseDate: date, Text: "same"},
+		{ID: 3, Group: "Muse", Title: "Locked", ReleaseDate: date, Text: "old", Locked: true},
+		{ID: 4, Group: "Muse", Title: "Locked later", ReleaseDate: date, Text: "old"},
+		{ID: 5, Group: "Muse", Title: "Gone", ReleaseDate: date},
+	}
+	up := fakeUpstream{
+		"Muse/Changed":      {ReleaseDate: date, Text: "new"},
+		"Muse/Same":         {ReleaseDate: date, Text: "same"},
+		"Muse/Locked":       {ReleaseDate: date, Text: "new"},
+		"Muse/Locked later": {ReleaseDate: date, Text: "new"},
+	}
+
+	tests := []struct {
+		name     string
+		lockHeld bool
+		want     Stats
+		updated  []int
+	}{
+		{
+			name:    "pass",
+			want:    Stats{Checked: 5, Skipped: 2, Changed: 1, NotFound: 1},
+			updated: []int{1},
+		},
+		{
+			name:     "another instance holds lock",
+			lockHeld: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeStorage{songs: songs, lockedAt: map[int]bool{4: true}, lockHeld: tt.lockHeld}
+			r := NewRefresher(db, up, Config{Mode: ModeApply, BatchSize: 2}, logger.New("error", io.Discard))
+
+			stats, err := r.Run(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats != tt.want {
+				t.Errorf("stats = %+v, want %+v", stats, tt.want)
+			}
+			if !slices.Equal(db.updated, tt.updated) {
+				t.Errorf("updated %v, want %v", db.updated, tt.updated)
+			}
+		})
+	}
+}
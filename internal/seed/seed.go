@@ -0,0 +1,138 @@
+// Deterministic synthetic songs for demo data and testing of pagination and search
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/Rolan335/Musiclib/internal/entity"
+)
+
+var (
+	adjectives = []string{
+		"Velvet", "Electric", "Silent", "Broken", "Golden", "Midnight", "Crimson", "Hollow",
+		"Neon", "Wild", "Paper", "Lonely", "Northern", "Burning", "Frozen", "Lucky",
+	}
+	nouns = []string{
+		"Tigers", "Ghosts", "Rivers", "Machines", "Sparrows", "Kings", "Lights", "Wolves",
+		"Mirrors", "Satellites", "Roses", "Strangers", "Engines", "Horses", "Echoes", "Saints",
+	}
+	titleNouns = []string{
+		"Heart", "Road", "Summer", "Fire", "Rain", "City", "Dream", "Ocean",
+		"Night", "Train", "Sky", "Letter", "Garden", "Shadow", "Radio", "Window",
+	}
+	subjects = []string{"I", "You", "We", "She", "They", "Nobody", "Somebody"}
+	verbs    = []string{
+		"walk", "run", "wait", "call", "fall", "dance", "drive", "burn",
+		"hide", "sing", "dream", "break", "shine", "fade", "pray", "stay",
+	}
+	places = []string{
+		"through the empty streets", "under the falling stars", "by the river side", "in the neon glow",
+		"across the frozen lake", "down the endless road", "behind the closing doors", "on the edge of town",
+		"inside a paper house", "beneath the silver moon", "along the railway line", "into the morning light",
+	}
+	times = []string{"tonight", "again", "forever", "alone", "tomorrow", "at dawn", "once more", "too late"}
+	hooks = []string{
+		"Oh, don't let me go", "Hold on, hold on", "We are the %s", "Say it one more time",
+		"This is our %s", "Take me home", "Never look back", "Light up the %s",
+	}
+)
+
+// Generator produces the same songs for the same seed
+type Generator struct {
+	rng *rand.Rand
+}
+
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+// Song returns next synthetic song
+func (g *Generator) Song() entity.Song {
+	group := g.pick(adjectives) + " " + g.pick(nouns)
+	if g.rng.IntN(3) == 0 {
+		group = "The " + group
+	}
+	word := g.pick(titleNouns)
+	title := g.title(word)
+	start := time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)
+	days := int(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC).Sub(start).Hours() / 24)
+	return entity.Song{
+		Group:       group,
+		Title:       title,
+		ReleaseDate: start.AddDate(0, 0, g.rng.IntN(days+1)),
+		Text:        g.lyrics(strings.ToLower(word)),
+		Link:        "https://www.youtube.com/watch?v=" + g.videoID(),
+	}
+}
+
+func (g *Generator) title(word string) string {
+	switch g.rng.IntN(4) {
+	case 0:
+		return word
+	case 1:
+		return g.pick(adjectives) + " " + word
+	case 2:
+		//words are ascii
+		t := g.pick(times)
+		return word + " " + strings.ToUpper(t[:1]) + t[1:]
+	default:
+		return "The " + word + " Song"
+	}
+}
+
+// lyrics are verses with repeated chorus separated by empty line, as GetSongText expects
+func (g *Generator) lyrics(word string) string {
+	chorus := g.stanza(2+g.rng.IntN(3), func() string {
+		hook := g.pick(hooks)
+		if strings.Contains(hook, "%s") {
+			hook = fmt.Sprintf(hook, word)
+		}
+		return hook
+	})
+	verses := 2 + g.rng.IntN(3)
+	blocks := make([]string, 0, verses*2+1)
+	for i := 0; i < verses; i++ {
+		blocks = append(blocks, g.stanza(4, g.line))
+		if i > 0 || g.rng.IntN(2) == 0 {
+			blocks = append(blocks, chorus)
+		}
+	}
+	if g.rng.IntN(3) == 0 {
+		//bridge before the last chorus
+		blocks = append(blocks, g.stanza(2, g.line), chorus)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func (g *Generator) stanza(lines int, line func() string) string {
+	result := make([]string, lines)
+	for i := range result {
+		result[i] = line()
+	}
+	return strings.Join(result, "\n")
+}
+
+func (g *Generator) line() string {
+	subject := g.pick(subjects)
+	verb := g.pick(verbs)
+	if subject == "She" || subject == "Nobody" || subject == "Somebody" {
+		verb += "s"
+	}
+	return subject + " " + verb + " " + g.pick(places) + " " + g.pick(times)
+}
+
+func (g *Generator) videoID() string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	id := make([]byte, 11)
+	for i := range id {
+		id[i] = alphabet[g.rng.IntN(len(alphabet))]
+	}
+	return string(id)
+}
+
+func (g *Generator) pick(words []string) string {
+	return words[g.rng.IntN(len(words))]
+}
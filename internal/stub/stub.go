@@ -0,0 +1,158 @@
+// Local stand-in for the external music info api, serving responses from fixtures
+package stub
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/pkg/musicinfo"
+)
+
+const (
+	// respond with NotFoundStatus if fixture is missing
+	NotFoundStatus = "status"
+	// respond with generated placeholder detail if fixture is missing
+	NotFoundGenerate = "generate"
+)
+
+type Config struct {
+	Port           string        `env:"STUB_PORT" envDefault:":8081"`
+	FixturesDir    string        `env:"STUB_FIXTURES_DIR" envDefault:"./testdata/musicinfo"`
+	Latency        time.Duration `env:"STUB_LATENCY"`
+	LatencyJitter  time.Duration `env:"STUB_LATENCY_JITTER"`
+	ErrorRate      float64       `env:"STUB_ERROR_RATE"`
+	NotFoundMode   string        `env:"STUB_NOT_FOUND_MODE" envDefault:"status"`
+	NotFoundStatus int           `env:"STUB_NOT_FOUND_STATUS" envDefault:"400"`
+	Seed           uint64        `env:"STUB_SEED" envDefault:"1"`
+	LogLevel       string        `env:"LOG_LEVEL"`
+}
+
+// Fixture is one song known to stub. If Status is set and not 200, stub responds with it and raw Body
+// so fixtures can describe upstream quirks
+type Fixture struct {
+	Group  string          `json:"group"`
+	Song   string          `json:"song"`
+	Status int             `json:"status,omitempty"`
+	Detail json.RawMessage `json:"detail,omitempty"`
+	Body   string          `json:"body,omitempty"`
+}
+
+type Server struct {
+	cfg      Config
+	fixtures map[string]Fixture
+	log      *logger.Log
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+var _ musicinfo.ServerInterface = (*Server)(nil)
+
+func NewServer(cfg Config, l *logger.Log) (*Server, error) {
+	fixtures, err := LoadFixtures(cfg.FixturesDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		cfg:      cfg,
+		fixtures: fixtures,
+		log:      l,
+		rnd:      rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+	}, nil
+}
+
+// LoadFixtures reads every *.json file in dir. File contains one fixture or array of fixtures
+func LoadFixtures(dir string) (map[string]Fixture, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixtures: %w", err)
+	}
+	fixtures := make(map[string]Fixture)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", file, err)
+		}
+		var list []Fixture
+		if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+			err = json.Unmarshal(data, &list)
+		} else {
+			var fixture Fixture
+			err = json.Unmarshal(data, &fixture)
+			list = append(list, fixture)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", file, err)
+		}
+		for _, fixture := range list {
+			fixtures[key(fixture.Group, fixture.Song)] = fixture
+		}
+	}
+	return fixtures, nil
+}
+
+func key(group string, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
+
+func (s *Server) GetInfo(c *gin.Context, params musicinfo.GetInfoParams) {
+	delay, fail := s.roll()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+	if fail {
+		s.log.Debug("stub: injected error", "group", params.Group, "song", params.Song)
+		c.String(http.StatusInternalServerError, "injected error")
+		return
+	}
+
+	fixture, ok := s.fixtures[key(params.Group, params.Song)]
+	if !ok {
+		s.log.Debug("stub: fixture not found", "group", params.Group, "song", params.Song)
+		if s.cfg.NotFoundMode == NotFoundGenerate {
+			c.JSON(http.StatusOK, generate(params))
+			return
+		}
+		c.String(s.cfg.NotFoundStatus, "song not found")
+		return
+	}
+	if fixture.Status != 0 && fixture.Status != http.StatusOK {
+		c.String(fixture.Status, fixture.Body)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", fixture.Detail)
+}
+
+// roll returns delay before response and whether to respond with injected error
+func (s *Server) roll() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delay := s.cfg.Latency
+	if s.cfg.LatencyJitter > 0 {
+		delay += time.Duration(s.rnd.Int64N(int64(s.cfg.LatencyJitter)))
+	}
+	return delay, s.cfg.ErrorRate > 0 && s.rnd.Float64() < s.cfg.ErrorRate
+}
+
+// generate builds placeholder detail for unknown song
+func generate(params musicinfo.GetInfoParams) musicinfo.SongDetail {
+	return musicinfo.SongDetail{
+		ReleaseDate: "01.01.2000",
+		Text:        params.Song + "\nby " + params.Group + "\n\n" + params.Song,
+		Link:        "https://www.youtube.com/results?search_query=" + strings.ReplaceAll(params.Group+" "+params.Song, " ", "+"),
+	}
+}
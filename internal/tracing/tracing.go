@@ -0,0 +1,155 @@
+// OpenTelemetry tracing: provider with OTLP or file exporter and W3C trace context propagation
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+)
+
+const (
+	// spans are not exported, trace context is still propagated
+	ExporterNone = "none"
+	// spans are sent to OTLP/HTTP collector
+	ExporterOTLP = "otlp"
+	// spans are written to file as JSON lines, for offline use
+	ExporterFile = "file"
+)
+
+// spans not exported before shutdown are dropped after this timeout
+const shutdownTimeout = 5 * time.Second
+
+type Config struct {
+	Exporter    string `env:"TRACING_EXPORTER" envDefault:"none"`
+	ServiceName string `env:"TRACING_SERVICE_NAME"`
+	// host:port of collector, e.g. "jaeger:4318"
+	OTLPEndpoint string `env:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool   `env:"TRACING_OTLP_INSECURE"`
+	File         string `env:"TRACING_FILE"`
+	// part of root traces sampled, from 0 to 1. Sampling decision of caller is respected
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+}
+
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	file     io.Closer
+	log      *logger.Log
+}
+
+// New sets global tracer provider and W3C trace context propagator
+func New(ctx context.Context, cfg Config, l *logger.Log) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	p := &Provider{log: l}
+
+	var exporter sdktrace.SpanExporter
+	switch strings.ToLower(cfg.Exporter) {
+	case ExporterNone, "":
+		return p, nil
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{}
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		otlp, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+		}
+		exporter = otlp
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, fmt.Errorf("tracing file is empty")
+		}
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tracing file: %w", err)
+		}
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		p.file = file
+		exporter = stdout
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio > 1 {
+		cfg.SampleRatio = 1
+	}
+	p.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName(cfg)))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(p.provider)
+	return p, nil
+}
+
+// Close flushes spans and stops exporter
+func (p *Provider) Close() {
+	if p.provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := p.provider.Shutdown(ctx); err != nil {
+		p.log.Error("tracing: failed to shutdown provider", "error", err.Error())
+	}
+	if p.file != nil {
+		p.file.Close()
+	}
+}
+
+// Middleware creates server span per request, trace context of caller is extracted from headers
+func Middleware(cfg Config) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName(cfg))
+}
+
+// HTTPClient returns client creating spans for outgoing requests and injecting trace context to them
+func HTTPClient() *http.Client {
+	return &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
+}
+
+// End records err on span and ends it. Should be called in defer with named error result
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func serviceName(cfg Config) string {
+	if cfg.ServiceName == "" {
+		return "musiclib"
+	}
+	return cfg.ServiceName
+}
@@ -0,0 +1,229 @@
+// Validation of requests and responses against api/musiclib/openapi.yaml
+package validation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/problem"
+	"github.com/Rolan335/Musiclib/pkg/api"
+)
+
+type Config struct {
+	Enabled bool `env:"VALIDATION_ENABLED" envDefault:"true"`
+	// responses are validated too, violations are logged
+	Responses bool `env:"VALIDATION_RESPONSES"`
+	// bounds of page sizes, override maximum from spec
+	MaxPageSize     int `env:"VALIDATION_MAX_PAGE_SIZE"`
+	MaxTextPageSize int `env:"VALIDATION_MAX_TEXT_PAGE_SIZE"`
+}
+
+// page size parameters per path, their maximum is taken from config
+var pageSizeParams = map[string]string{
+	"/songs":           "page_size",
+	"/songs/{id}/text": "pageSize",
+}
+
+type Validator struct {
+	cfg     Config
+	spec    *openapi3.T
+	options *openapi3filter.Options
+	log     *logger.Log
+}
+
+func NewValidator(cfg Config, l *logger.Log) (*Validator, error) {
+	spec, err := api.GetSwagger()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load api spec: %w", err)
+	}
+	bounds := map[string]int{
+		"/songs":           cfg.MaxPageSize,
+		"/songs/{id}/text": cfg.MaxTextPageSize,
+	}
+	for path, name := range pageSizeParams {
+		if bounds[path] <= 0 {
+			continue
+		}
+		item := spec.Paths.Find(path)
+		if item == nil || item.Get == nil {
+			return nil, fmt.Errorf("api spec has no GET %s", path)
+		}
+		param := item.Get.Parameters.GetByInAndName(openapi3.ParameterInQuery, name)
+		if param == nil || param.Schema == nil || param.Schema.Value == nil {
+			return nil, fmt.Errorf("api spec has no parameter %s of GET %s", name, path)
+		}
+		maximum := float64(bounds[path])
+		param.Schema.Value.Max = &maximum
+	}
+	return &Validator{
+		cfg:  cfg,
+		spec: spec,
+		options: &openapi3filter.Options{
+			MultiError: true,
+			//authentication is done by auth middlewares
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+		log: l,
+	}, nil
+}
+
+// RequestMiddleware rejects requests violating spec with 400 problem details
+func (v *Validator) RequestMiddleware() api.MiddlewareFunc {
+	return func(c *gin.Context) {
+		if !v.cfg.Enabled {
+			return
+		}
+		input, ok := v.input(c)
+		if !ok {
+			return
+		}
+		err := openapi3filter.ValidateRequest(c.Request.Context(), input)
+		if err == nil {
+			return
+		}
+		v.log.BadInput(c.Request.Context(), "validation: RequestMiddleware", c.Request.Method+" "+c.Request.URL.String(), err)
+		problem.Abort(c, problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "request violates api spec").
+			WithErrors(fieldErrors(err)...))
+	}
+}
+
+// ResponseMiddleware validates responses of api operations and logs violations.
+// Should be registered on router, because it wraps handler
+func (v *Validator) ResponseMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !v.cfg.Enabled || !v.cfg.Responses {
+			c.Next()
+			return
+		}
+		input, ok := v.input(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		writer := &bodyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		response := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 writer.Status(),
+			Header:                 writer.Header(),
+			Options:                v.options,
+		}
+		response.SetBodyBytes(writer.body.Bytes())
+		if err := openapi3filter.ValidateResponse(c.Request.Context(), response); err != nil {
+			v.log.Error("validation: response violates api spec",
+				"method", c.Request.Method,
+				"path", c.FullPath(),
+				"status", writer.Status(),
+				"errors", fieldErrors(err),
+			)
+		}
+	}
+}
+
+// input finds operation of the spec matching gin route
+func (v *Validator) input(c *gin.Context) (*openapi3filter.RequestValidationInput, bool) {
+	path := specPath(c.FullPath())
+	item := v.spec.Paths.Find(path)
+	if item == nil {
+		return nil, false
+	}
+	op := item.GetOperation(c.Request.Method)
+	if op == nil {
+		return nil, false
+	}
+	params := make(map[string]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return &openapi3filter.RequestValidationInput{
+		Request:    c.Request,
+		PathParams: params,
+		Route: &routers.Route{
+			Spec:      v.spec,
+			Path:      path,
+			PathItem:  item,
+			Method:    c.Request.Method,
+			Operation: op,
+		},
+		Options: v.options,
+	}, true
+}
+
+// specPath converts gin path "/songs/:id" to spec path "/songs/{id}"
+func specPath(ginPath string) string {
+	parts := strings.Split(ginPath, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// fieldErrors converts errors of openapi3filter to field errors of problem details
+func fieldErrors(err error) []problem.FieldError {
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		multi = openapi3.MultiError{err}
+	}
+	result := make([]problem.FieldError, 0, len(multi))
+	for _, e := range multi {
+		field := ""
+		var reqErr *openapi3filter.RequestError
+		var respErr *openapi3filter.ResponseError
+		switch {
+		case errors.As(e, &reqErr) && reqErr.Parameter != nil:
+			field = reqErr.Parameter.Name
+		case errors.As(e, &reqErr) && reqErr.RequestBody != nil:
+			field = "body"
+		case errors.As(e, &respErr):
+			field = "response"
+		}
+		var nested openapi3.MultiError
+		if errors.As(e, &nested) && len(nested) > 0 && e != error(nested) {
+			for _, ne := range nested {
+				result = append(result, schemaFieldError(field, ne))
+			}
+			continue
+		}
+		result = append(result, schemaFieldError(field, e))
+	}
+	return result
+}
+
+func schemaFieldError(field string, err error) problem.FieldError {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return problem.FieldError{Field: field, Reason: err.Error()}
+	}
+	if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+		field = strings.TrimPrefix(field+"."+strings.Join(pointer, "."), ".")
+	}
+	return problem.FieldError{Field: field, Reason: schemaErr.Reason}
+}
+
+// bodyWriter copies response body for validation
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
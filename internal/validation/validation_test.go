@@ -0,0 +1,143 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Rolan335/Musiclib/internal/logger"
+	"github.com/Rolan335/Musiclib/internal/problem"
+)
+
+// newRouter registers routes of the spec with handlers writing body as is
+func newRouter(t *testing.T, cfg Config, logs *bytes.Buffer) *gin.Engine {
+	t.Helper()
+	v, err := NewValidator(cfg, logger.New("info", logs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(v.ResponseMiddleware())
+	check := gin.HandlerFunc(v.RequestMiddleware())
+	r.GET("/songs", check, func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(`[]`)) })
+	r.POST("/songs", check, func(c *gin.Context) { c.Data(http.StatusCreated, "application/json", []byte(`{"id":"one"}`)) })
+	r.GET("/songs/:id/text", check, func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", []byte(`{"text":[],"sections":[]}`))
+	})
+	r.GET("/metrics", check, func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestRequestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		method string
+		target string
+		body   string
+		status int
+		fields []string
+	}{
+		{name: "valid query", cfg: Config{Enabled: true}, method: http.MethodGet, target: "/songs?page=2&page_size=100&date_from=2006-07-16", status: http.StatusOK},
+		{name: "page size above spec maximum", cfg: Config{Enabled: true}, method: http.MethodGet, target: "/songs?page_size=101", status: http.StatusBadRequest, fields: []string{"page_size"}},
+		{name: "several invalid params", cfg: Config{Enabled: true}, method: http.MethodGet, target: "/songs?page=0&date_to=16.07.2006", status: http.StatusBadRequest, fields: []string{"page", "date_to"}},
+		{name: "maximum from config", cfg: Config{Enabled: true, MaxTextPageSize: 5}, method: http.MethodGet, target: "/songs/1/text?pageSize=6", status: http.StatusBadRequest, fields: []string{"pageSize"}},
+		{name: "within maximum from config", cfg: Config{Enabled: true, MaxTextPageSize: 5}, method: http.MethodGet, target: "/songs/1/text?pageSize=5", status: http.StatusOK},
+		{name: "invalid path param", cfg: Config{Enabled: true}, method: http.MethodGet, target: "/songs/abc/text", status: http.StatusBadRequest, fields: []string{"id"}},
+		{name: "unknown section", cfg: Config{Enabled: true}, method: http.MethodGet, target: "/songs/1/text?section=solo", status: http.StatusBadRequest, fields: []string{"section"}},
+		{name: "valid body", cfg: Config{Enabled: true}, method: http.MethodPost, target: "/songs", body: `{"group":"Muse","title":"Uprising"}`, status: http.StatusCreated},
+		{name: "body without title", cfg: Config{Enabled: true}, method: http.MethodPost, target: "/songs", body: `{"group":"Muse"}`, status: http.StatusBadRequest, fields: []string{"body.title"}},
+		{name: "empty group", cfg: Config{Enabled: true}, method: http.MethodPost, target: "/songs", body: `{"group":"","title":"Uprising"}`, status: http.StatusBadRequest, fields: []string{"body.group"}},
+		{name: "route not in spec", cfg: Config{Enabled: true}, method: http.MethodGet, target: "/metrics?page=0", status: http.StatusOK},
+		{name: "disabled", cfg: Config{}, method: http.MethodGet, target: "/songs?page_size=101", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRouter(t, tt.cfg, &bytes.Buffer{})
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if len(tt.fields) == 0 {
+				return
+			}
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Code != problem.CodeValidationFailed {
+				t.Errorf("code = %q, want %q", p.Code, problem.CodeValidationFailed)
+			}
+			got := make(map[string]bool, len(p.Errors))
+			for _, e := range p.Errors {
+				got[e.Field] = true
+			}
+			for _, field := range tt.fields {
+				if !got[field] {
+					t.Errorf("no error of field %q in %+v", field, p.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		method    string
+		target    string
+		body      string
+		violation bool
+	}{
+		{name: "valid response", cfg: Config{Enabled: true, Responses: true}, method: http.MethodGet, target: "/songs/1/text", violation: false},
+		{name: "invalid response", cfg: Config{Enabled: true, Responses: true}, method: http.MethodPost, target: "/songs", body: `{"group":"Muse","title":"Uprising"}`, violation: true},
+		{name: "responses aren't validated", cfg: Config{Enabled: true}, method: http.MethodPost, target: "/songs", body: `{"group":"Muse","title":"Uprising"}`, violation: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			r := newRouter(t, tt.cfg, &logs)
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			//response is sent unchanged, violation is only logged
+			if w.Code >= http.StatusBadRequest {
+				t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+			}
+			if got := strings.Contains(logs.String(), "response violates api spec"); got != tt.violation {
+				t.Errorf("violation logged = %v, want %v: %s", got, tt.violation, logs.String())
+			}
+		})
+	}
+}
+
+func TestSpecPath(t *testing.T) {
+	tests := map[string]string{
+		"/songs":               "/songs",
+		"/songs/:id/text":      "/songs/{id}/text",
+		"/proposals/:id/apply": "/proposals/{id}/apply",
+		"":                     "",
+	}
+	for in, want := range tests {
+		if got := specPath(in); got != want {
+			t.Errorf("specPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
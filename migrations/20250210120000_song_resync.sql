@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS song_proposals(
+    ID SERIAL PRIMARY KEY NOT NULL,
+    song_id INTEGER NOT NULL UNIQUE REFERENCES songs(ID) ON DELETE CASCADE,
+    release_date DATE,
+    text TEXT,
+    link TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_proposals;
+ALTER TABLE songs DROP COLUMN IF EXISTS locked;
+-- +goose StatementEnd
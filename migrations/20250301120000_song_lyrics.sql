@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sections of text, NULL until parsed
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS lyrics JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE songs DROP COLUMN IF EXISTS lyrics;
+-- +goose StatementEnd
@@ -4,31 +4,173 @@
 package api
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 	"github.com/oapi-codegen/runtime"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+const (
+	ApiKeyAuthScopes = "ApiKeyAuth.Scopes"
+	BearerAuthScopes = "BearerAuth.Scopes"
+)
+
+// Defines values for Level.
+const (
+	Debug Level = "debug"
+	Error Level = "error"
+	Info  Level = "info"
+	Warn  Level = "warn"
+)
+
+// Defines values for ProblemCode.
+const (
+	ApiKeyNotFound            ProblemCode = "api_key_not_found"
+	Forbidden                 ProblemCode = "forbidden"
+	Internal                  ProblemCode = "internal"
+	InvalidBody               ProblemCode = "invalid_body"
+	InvalidParameter          ProblemCode = "invalid_parameter"
+	NotFound                  ProblemCode = "not_found"
+	ProposalNotFound          ProblemCode = "proposal_not_found"
+	RateLimited               ProblemCode = "rate_limited"
+	SongLocked                ProblemCode = "song_locked"
+	SongNotFound              ProblemCode = "song_not_found"
+	Timeout                   ProblemCode = "timeout"
+	Unauthorized              ProblemCode = "unauthorized"
+	UpstreamContractViolation ProblemCode = "upstream_contract_violation"
+	UpstreamSongNotFound      ProblemCode = "upstream_song_not_found"
+	UpstreamUnavailable       ProblemCode = "upstream_unavailable"
+	ValidationFailed          ProblemCode = "validation_failed"
+)
+
+// Defines values for Role.
+const (
+	Admin  Role = "admin"
+	Editor Role = "editor"
+	Viewer Role = "viewer"
+)
+
+// Defines values for SectionType.
+const (
+	Bridge    SectionType = "bridge"
+	Chorus    SectionType = "chorus"
+	Intro     SectionType = "intro"
+	Outro     SectionType = "outro"
+	PreChorus SectionType = "pre-chorus"
+	Verse     SectionType = "verse"
+)
+
+// APIKey defines model for APIKey.
+type APIKey struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Id        int       `json:"id"`
+
+	// Key Только в ответе на создание ключа
+	Key       *string    `json:"key,omitempty"`
+	Name      string     `json:"name"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+
+	// Role viewer - чтение, editor - добавление и изменение песен, admin - удаление и управление ключами
+	Role Role `json:"role"`
+}
+
+// Level defines model for Level.
+type Level string
+
+// LogLevel defines model for LogLevel.
+type LogLevel struct {
+	Level Level `json:"level"`
+
+	// Packages Уровни для отдельных пакетов, например postgres
+	Packages *map[string]Level `json:"packages,omitempty"`
+}
+
+// Problem Ошибка в формате RFC 7807 (application/problem+json)
+type Problem struct {
+	// Code Стабильный машиночитаемый код ошибки
+	Code   ProblemCode `json:"code"`
+	Detail *string     `json:"detail,omitempty"`
+
+	// Errors Ошибки отдельных полей
+	Errors *[]struct {
+		Field  string `json:"field"`
+		Reason string `json:"reason"`
+	} `json:"errors,omitempty"`
+	Instance  *string `json:"instance,omitempty"`
+	RequestId *string `json:"requestId,omitempty"`
+	Status    int     `json:"status"`
+	Title     string  `json:"title"`
+	Type      string  `json:"type"`
+}
+
+// ProblemCode Стабильный машиночитаемый код ошибки
+type ProblemCode string
+
+// Proposal defines model for Proposal.
+type Proposal struct {
+	CreatedAt   time.Time  `json:"createdAt"`
+	Id          int        `json:"id"`
+	Link        *string    `json:"link,omitempty"`
+	ReleaseDate *time.Time `json:"releaseDate,omitempty"`
+	SongId      int        `json:"songId"`
+	Text        *string    `json:"text,omitempty"`
+}
+
+// Role viewer - чтение, editor - добавление и изменение песен, admin - удаление и управление ключами
+type Role string
+
+// Section defines model for Section.
+type Section struct {
+	Label  *string     `json:"label,omitempty"`
+	Lines  []string    `json:"lines"`
+	Repeat int         `json:"repeat"`
+	Type   SectionType `json:"type"`
+}
+
+// SectionType defines model for SectionType.
+type SectionType string
+
 // SongGet defines model for SongGet.
 type SongGet struct {
-	Group       string             `json:"group"`
-	Id          int                `json:"id"`
-	Link        string             `json:"link"`
-	ReleaseDate openapi_types.Date `json:"releaseDate"`
-	Text        string             `json:"text"`
-	Title       string             `json:"title"`
+	Group       string    `json:"group"`
+	Id          int       `json:"id"`
+	Link        string    `json:"link"`
+	Locked      bool      `json:"locked"`
+	ReleaseDate time.Time `json:"releaseDate"`
+	Text        string    `json:"text"`
+	Title       string    `json:"title"`
 }
 
 // SongPatch defines model for SongPatch.
 type SongPatch struct {
-	Group       *string             `json:"group,omitempty"`
-	Link        *string             `json:"link,omitempty"`
-	ReleaseDate *openapi_types.Date `json:"releaseDate,omitempty"`
-	Text        *string             `json:"text,omitempty"`
-	Title       *string             `json:"title,omitempty"`
+	Group *string `json:"group,omitempty"`
+	Link  *string `json:"link,omitempty"`
+
+	// Locked Защита от изменений при синхронизации с внешним API
+	Locked *bool `json:"locked,omitempty"`
+
+	// ReleaseDate Дата в формате DD.MM.YYYY
+	ReleaseDate *string `json:"releaseDate,omitempty"`
+	Text        *string `json:"text,omitempty"`
+	Title       *string `json:"title,omitempty"`
+}
+
+// PostAuthKeysJSONBody defines parameters for PostAuthKeys.
+type PostAuthKeysJSONBody struct {
+	Name string `json:"name"`
+
+	// Role viewer - чтение, editor - добавление и изменение песен, admin - удаление и управление ключами
+	Role Role `json:"role"`
 }
 
 // GetSongsParams defines parameters for GetSongs.
@@ -57,27 +199,57 @@ type GetSongsParams struct {
 
 // PostSongsJSONBody defines parameters for PostSongs.
 type PostSongsJSONBody struct {
-	Group *string `json:"group,omitempty"`
-	Title *string `json:"title,omitempty"`
+	Group string `json:"group"`
+	Title string `json:"title"`
 }
 
-// PatchSongsIdJSONBody defines parameters for PatchSongsId.
-type PatchSongsIdJSONBody = map[string]interface{}
-
 // GetSongsIdTextParams defines parameters for GetSongsIdText.
 type GetSongsIdTextParams struct {
 	Page     *int `form:"page,omitempty" json:"page,omitempty"`
 	PageSize *int `form:"pageSize,omitempty" json:"pageSize,omitempty"`
+
+	// Section Только части указанного типа, пагинация по ним
+	Section *SectionType `form:"section,omitempty" json:"section,omitempty"`
 }
 
+// PutAdminLogLevelJSONRequestBody defines body for PutAdminLogLevel for application/json ContentType.
+type PutAdminLogLevelJSONRequestBody = LogLevel
+
+// PostAuthKeysJSONRequestBody defines body for PostAuthKeys for application/json ContentType.
+type PostAuthKeysJSONRequestBody PostAuthKeysJSONBody
+
 // PostSongsJSONRequestBody defines body for PostSongs for application/json ContentType.
 type PostSongsJSONRequestBody PostSongsJSONBody
 
 // PatchSongsIdJSONRequestBody defines body for PatchSongsId for application/json ContentType.
-type PatchSongsIdJSONRequestBody = PatchSongsIdJSONBody
+type PatchSongsIdJSONRequestBody = SongPatch
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Текущий уровень логирования и переопределения по пакетам
+	// (GET /admin/log-level)
+	GetAdminLogLevel(c *gin.Context)
+	// Изменение уровня логирования без перезапуска
+	// (PUT /admin/log-level)
+	PutAdminLogLevel(c *gin.Context)
+	// Получение списка API ключей
+	// (GET /auth/keys)
+	GetAuthKeys(c *gin.Context)
+	// Создание API ключа. Ключ возвращается один раз, хранится только его хеш
+	// (POST /auth/keys)
+	PostAuthKeys(c *gin.Context)
+	// Отзыв API ключа
+	// (DELETE /auth/keys/{id})
+	DeleteAuthKeysId(c *gin.Context, id int)
+	// Получение изменений песен, найденных при синхронизации с внешним API
+	// (GET /proposals)
+	GetProposals(c *gin.Context)
+	// Отклонение изменений
+	// (DELETE /proposals/{id})
+	DeleteProposalsId(c *gin.Context, id int)
+	// Применение изменений к песне
+	// (POST /proposals/{id}/apply)
+	PostProposalsIdApply(c *gin.Context, id int)
 	// Получение данных библиотеки с фильтрацией по всем полям и пагинацией
 	// (GET /songs)
 	GetSongs(c *gin.Context, params GetSongsParams)
@@ -87,6 +259,9 @@ type ServerInterface interface {
 	// Удаление песни
 	// (DELETE /songs/{id})
 	DeleteSongsId(c *gin.Context, id int)
+	// Получение песни
+	// (GET /songs/{id})
+	GetSongsId(c *gin.Context, id int)
 	// Изменение данных песни
 	// (PATCH /songs/{id})
 	PatchSongsId(c *gin.Context, id int)
@@ -104,11 +279,184 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc func(c *gin.Context)
 
+// GetAdminLogLevel operation middleware
+func (siw *ServerInterfaceWrapper) GetAdminLogLevel(c *gin.Context) {
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetAdminLogLevel(c)
+}
+
+// PutAdminLogLevel operation middleware
+func (siw *ServerInterfaceWrapper) PutAdminLogLevel(c *gin.Context) {
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PutAdminLogLevel(c)
+}
+
+// GetAuthKeys operation middleware
+func (siw *ServerInterfaceWrapper) GetAuthKeys(c *gin.Context) {
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetAuthKeys(c)
+}
+
+// PostAuthKeys operation middleware
+func (siw *ServerInterfaceWrapper) PostAuthKeys(c *gin.Context) {
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostAuthKeys(c)
+}
+
+// DeleteAuthKeysId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteAuthKeysId(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.DeleteAuthKeysId(c, id)
+}
+
+// GetProposals operation middleware
+func (siw *ServerInterfaceWrapper) GetProposals(c *gin.Context) {
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetProposals(c)
+}
+
+// DeleteProposalsId operation middleware
+func (siw *ServerInterfaceWrapper) DeleteProposalsId(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.DeleteProposalsId(c, id)
+}
+
+// PostProposalsIdApply operation middleware
+func (siw *ServerInterfaceWrapper) PostProposalsIdApply(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.PostProposalsIdApply(c, id)
+}
+
 // GetSongs operation middleware
 func (siw *ServerInterfaceWrapper) GetSongs(c *gin.Context) {
 
 	var err error
 
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
 	// Parameter object where we will unmarshal all parameters from the context
 	var params GetSongsParams
 
@@ -181,6 +529,10 @@ func (siw *ServerInterfaceWrapper) GetSongs(c *gin.Context) {
 // PostSongs operation middleware
 func (siw *ServerInterfaceWrapper) PostSongs(c *gin.Context) {
 
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
 	for _, middleware := range siw.HandlerMiddlewares {
 		middleware(c)
 		if c.IsAborted() {
@@ -205,6 +557,10 @@ func (siw *ServerInterfaceWrapper) DeleteSongsId(c *gin.Context) {
 		return
 	}
 
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
 	for _, middleware := range siw.HandlerMiddlewares {
 		middleware(c)
 		if c.IsAborted() {
@@ -215,6 +571,34 @@ func (siw *ServerInterfaceWrapper) DeleteSongsId(c *gin.Context) {
 	siw.Handler.DeleteSongsId(c, id)
 }
 
+// GetSongsId operation middleware
+func (siw *ServerInterfaceWrapper) GetSongsId(c *gin.Context) {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Param("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.GetSongsId(c, id)
+}
+
 // PatchSongsId operation middleware
 func (siw *ServerInterfaceWrapper) PatchSongsId(c *gin.Context) {
 
@@ -229,6 +613,10 @@ func (siw *ServerInterfaceWrapper) PatchSongsId(c *gin.Context) {
 		return
 	}
 
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
 	for _, middleware := range siw.HandlerMiddlewares {
 		middleware(c)
 		if c.IsAborted() {
@@ -253,6 +641,10 @@ func (siw *ServerInterfaceWrapper) GetSongsIdText(c *gin.Context) {
 		return
 	}
 
+	c.Set(ApiKeyAuthScopes, []string{})
+
+	c.Set(BearerAuthScopes, []string{})
+
 	// Parameter object where we will unmarshal all parameters from the context
 	var params GetSongsIdTextParams
 
@@ -272,6 +664,14 @@ func (siw *ServerInterfaceWrapper) GetSongsIdText(c *gin.Context) {
 		return
 	}
 
+	// ------------- Optional query parameter "section" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "section", c.Request.URL.Query(), &params.Section)
+	if err != nil {
+		siw.ErrorHandler(c, fmt.Errorf("Invalid format for parameter section: %w", err), http.StatusBadRequest)
+		return
+	}
+
 	for _, middleware := range siw.HandlerMiddlewares {
 		middleware(c)
 		if c.IsAborted() {
@@ -309,9 +709,151 @@ func RegisterHandlersWithOptions(router gin.IRouter, si ServerInterface, options
 		ErrorHandler:       errorHandler,
 	}
 
+	router.GET(options.BaseURL+"/admin/log-level", wrapper.GetAdminLogLevel)
+	router.PUT(options.BaseURL+"/admin/log-level", wrapper.PutAdminLogLevel)
+	router.GET(options.BaseURL+"/auth/keys", wrapper.GetAuthKeys)
+	router.POST(options.BaseURL+"/auth/keys", wrapper.PostAuthKeys)
+	router.DELETE(options.BaseURL+"/auth/keys/:id", wrapper.DeleteAuthKeysId)
+	router.GET(options.BaseURL+"/proposals", wrapper.GetProposals)
+	router.DELETE(options.BaseURL+"/proposals/:id", wrapper.DeleteProposalsId)
+	router.POST(options.BaseURL+"/proposals/:id/apply", wrapper.PostProposalsIdApply)
 	router.GET(options.BaseURL+"/songs", wrapper.GetSongs)
 	router.POST(options.BaseURL+"/songs", wrapper.PostSongs)
 	router.DELETE(options.BaseURL+"/songs/:id", wrapper.DeleteSongsId)
+	router.GET(options.BaseURL+"/songs/:id", wrapper.GetSongsId)
 	router.PATCH(options.BaseURL+"/songs/:id", wrapper.PatchSongsId)
 	router.GET(options.BaseURL+"/songs/:id/text", wrapper.GetSongsIdText)
 }
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/+xc724bN7Z/FYK3wG1xR5bsOEkr4KJwmk3rNtn1Jlm02dhrUBpKYj0aKhyOHTUQEDvb",
+	"pkW6zZcCuyiwXXS7D+A41kaxLeUVOK+wT7I45MxoRhopSuKoQTGAP1gcDnl4eP7xdw7nDq7yZou71JUe",
+	"Lt/BXrVBm0T/u7K2+gltw38twVtUSEZ1e1VQIqm9IuFHjYsmkbiMbSJpQbImxRaW7RbFZexJwdw67liY",
+	"2dCX3ibNlkNxeTHuwlxJ61RAny0zl029qmAtybiLy1j9Uw3UcfCtOlIDpA6QGgR76kB1gz3VRaqv9lGw",
+	"qwbqiTpU+6qvetB6pI6D74L7ah9bwylx09naXLr9+4WFhSwCXdKkKRIxa7a4kAVDTNYrgm7zrRdjg+CO",
+	"nuUtQWu4jP+nOOR9MWR88Sr06ejxb/lMUBuXbwL/QhrDQazELmzEM/HK57QqYabLdJs6ekGu34QRbFrx",
+	"69jCzK1xbOEdIlxgjxBc4I0MUi/zejxGevudqHnaKsy7HQu3SHWL1M2bxLYZ7Ctx1lIjzjTQiFz8HNxV",
+	"A3UAe47UoToOHhrZOFRdLS/94EHwJVLP1L460uIyUAeWlhj1LLireupEdYO7qMU9WRfUS4rKHRy3lkO+",
+	"dcZYPLJBhilZO7EmeMWhzQzR/jH4WvXUI3Wk9kG0gz+rQXBXnah9LdxXL32Azr9bOo/eJq2Ww6oEXiu2",
+	"zGj/97nH3XewNaqZ3KYZE/0U7Kl99Uj1Is6op0jPA/P31SC4r3q6S1edmIdHaqAOgaERhT1sxaLE3G3i",
+	"MHuzRQRpUkkFtuK2Crfb2ML6h6Z4s0aYQ0F8fZf4ssEF+0L/rHFRYbZNQQxdLjdr3Heh3eNufTPZAEvk",
+	"HnFSjaTFNrdoe3PsTYdXt/T4gki66bAmk2b2licFJc3NsfHjJ1XuSkGqcnObcUcTn3zqu2SbMIdUtPaB",
+	"hnNf6pVLKlziZGqRTSVhTtqw2BVkM9v9X4lqzLUR0IN2mGwgZqOzlvntcoki+sYG1UrrTRWo3gRlGKhj",
+	"1VVPgWxJm964ctcYddK2GgvqUOLRi0Rm2zRKPO6mXwnHbKP15NvrGDEPNZnnwatjQ41olKEkHj9LtcIG",
+	"IgRpw2/mepK41RFDXgSOesWz2cTf8qknV0eWXKqeIedqZ2jhbOXdWmGZnD1feI+crxQWa2fokv1udbmy",
+	"uJg1nCeJ9L3UWMul5SxnJ5l0Ruj8LZfo0qQ9Nw2pZYWmwCuOSfR0xuqnEQUxzZYxHhMsmFbAuUUCDnO3",
+	"0ottSNnyysXizs7OQpv70q/QhSpvFneIrDbe3/7/z7zWmU1SWLtyfSd7m4cinBp3qVQ6VyidLyyeu14q",
+	"lfXfH7E141KA76szLEfS25pB07eFRSZs1X6ud78axhFp/d9mdIcKVEDBffAgJhyyELWZ5NCsDtVAPVL7",
+	"6kAdR48RmIqeegLuUPWHrc9UN9iFnxYidpO5MOo9HWSlXg3uaW86MmQcgKmTlOMwBEKDJglbWI+daTmv",
+	"0apZ1lj4QSp0xKJ+0OBCi/DYKA5zzVuxuZugWUMjImiLEpmaYMnCTeaypt+ctMOhdk4LZMIFXYeuE1TS",
+	"UBuTkLXzyVES0R1zpeDgd6nwqPaZtFCNuBL/UxHMrsNj7kP3TLZzt/4hleNsrwvut9Jsv96g6AIl0qHe",
+	"G6PpoftPDlkjjkfjrhXOHUrc12cVIm0fDvc73kAVUmlbyObg9tvcR1su30GryPNrNSreX193h52qxNVd",
+	"GpQI1KSoyYkLPW5wH1WJX29IaPVdmwqk14ZagkrqetRbX3c/4jvIgRCiQmtcUD2SQ/UrdQ7DmLnC8Tx4",
+	"0kYe9x1EHFZvyOc9djPXPO7NPqJt9LFvU2zNYveMdA2d0kjMASwNhSXe4kzt4G59DQTlJeS3SW5fpm5d",
+	"NnB56exZrfHR78Vsy/K6JHckqvur2g++MQG6jurG7fVTZM40cBbuqX7wpT4b9XW//eAr1dOPkD4tdYOv",
+	"9ZMTtLK2mjz1zKomI9R9r48rWQeYixcXrlxZuHHjxo3kNHjx3ELp/AKoGIbToYToGZfxn9bX7TtLnfX1",
+	"hdQ/y523ciWbRcleSHwzj7MerfqCyfY1cFhGaVZa7BPaXvGlVigG+9SgxKYiQiPK+LPCytpqAXCioSvV",
+	"bwHJFygRVETvV/SvS5EV/fjT69gyeJOWOP10OAqoE+50OiFkUb4TMQBf8T1WRZdZRRDRDsUYHJ+RyMWF",
+	"0kIJJuct6pIWw2V8RjdpaWvodRV13FF0eL0Qoxl14/XAZuizH0R2+EMqV6BrjIaAOngtDnIAvZdKJazP",
+	"3a6krn4/eVj/PDwYmRDguUhHNIdedFrNLvM60pR6sLLl0uKUWZMQweyzRzBFxuR/SB7c9fRn5jn9pQgo",
+	"QDUukOAORbyGZIOiKnEcE0csL703T5KuEkmRxhYQF8gmzGmjWz6XBNHbVUptalvIoxRdpVK0Cys1SQUK",
+	"Fadj4bOl0jyJXQ0BCuRRsU0FMpif1ni/2SSibWDWrjoK7oGjUU9RcC9E17qqH3yL1LEaqMeqFzZqoBXw",
+	"tp45J9xVXTXQHqhrEAfVjXo8U4MkBrevTjQm6Geo2pqfoWr6dH4BMKXXo2XDQEQKn3Z+ce1GRMtKtUHc",
+	"OjW6NldZuUBsFHI9NzS5oXkNhuZvY1hDbGz6wcOJpuaR6qonQ3PzRMP494JdAM/1FEUQneIWbXtTnbkv",
+	"G59An1fU9BhOmMaqMH82hjGMc25lbRVp0nOdy3Xu9HXuHzqHei+4P9S5XfVM9Yz6QAQ9xOwgOwAumntZ",
+	"Ppp7aRV6Of+cPpm/TOr1ZfOoyRTqOIAwS0SweGoRQWQexjf1ml+tUs+r+Y7TRiESnAcDuWH61Rmmn0ZK",
+	"N1KWaH8BqR/M/wBdQc8DyDUE30CmOtgLdnXOXx0C5oXgiXpiIQ1+mbDB9NCp/7iCpKseqwEKvgQcbCRu",
+	"KN5hdscgXA41WFfa+l3U7ZH902maOAXu4fLNECEBlGGIjzAbjxoVK8HdUUi8s5EdmUwxEGEdSq6imSpa",
+	"Wp4nSWEgl8jf52bi1c3Ej8GeehI8UAcjBsIocFQfMjXwX4s7zSPyj2abJfYfUpYrcO5j5xH8Z+aPhvl+",
+	"XSP3VCN5/bhy6OXSS2n9nNHBxgrxBnlYOB/kLvaNcLGReOQ+9tR97JFG3/pTDEWWRheB+HZUNpsNGiR0",
+	"ekX3fjMUW7M91+s3S6+5GK2B1cTMVb+hkASKVU1RBiJ1wlxPIuJL3iSSVcMMjZebntOIUKJ6/KmmB6mj",
+	"KEzpq64xRLqyd1rYf013GDM2aSLVv0xlfLCn4YWvEqnLiCwokgx2wwJqjS3oyuqHuv4bl/Etn4r20HJF",
+	"5UxjxmpYgPECREA89iROhnw35EJvwvRxae8LTA9hogaEzaR6gUfBbrAX3EtMiN7WPDjUDyAKPFL770yi",
+	"wlRtvSoP/nP3+yQBMH+wCzlmKD490qwBxvTVAGTkEIqPggfobag7Kly5Urh4cRJ9NlwQqAneTBGZKvLD",
+	"1ikRfagGp0Ku5K9I7N/VILz5Em6hEaqvggcTpm2RelqUbFojviN1WdG0qtiMyX/Q6tODowjMDqAeCv4C",
+	"e2nUzNzSia51JclT3SnkbXrsi0k0lnRlVEhkqfQckjfmAQ5EJbYzYANRjZNNJPkFEgCr5lIPSljPPFLK",
+	"MYq5YBQmKRDCD4/0taZj1dO3mrr6glOwCwWnI6YX0peh1zzQeMZJeOspeAj/9kwx0mN97S1+YXq+Mwoh",
+	"TifZmVGGfKWNKg7ndhttE4e6krn0xcuRM4pDP21QU+PqOZS2XqJENJk4TRdozyN1muabuUww7mOy6Jh2",
+	"4LPtPJWaHzbxtdQBEzEX0duhndK4ZW7TX8KmAx1L86TjN4k9g9N64ooucJFJD0UmCJk7vdQzchfe9DU0",
+	"z1XyPiSS7pA2ii4Qj3jC77Ou6PV1LdxAPU0e/YYH4Blxde3I5oapLz8HejO05tDbm2cNc+t3ChHtz6N3",
+	"ZROKa02Hq+ab9jqVUrb4SDtBtobn11zTc03/1Z9dU7reiu6jjhwtofm16/vp358ZXrGd6cy3nH1l1Bzt",
+	"U7xCupz/WVhBMEA6EDKRjzajwYMcAcvNWG7G5nYvJw3BTTx4FKO72M8Jaq6bdNDpGzrrzuknLiYNeG1y",
+	"puGFEg3W1O/cQW2jTpD0xpNGj01yrgdApjUGZyZyhj11MiFn4oWfVrFmNfmpL5e8ahw5mncEsw85MJC4",
+	"E71uqDI7CB5YSIvcseoNGdJVT6ezRH8jMM4SAx+6wd7Yd9JCDniZ3xvsIvVv1R3O2UskQ3ViKppsYCBl",
+	"/fW0XV06E6LNB5DIMjfYkl/amoHHWR+kifQrHmf2b9mkf49+dCbMz0a82JgFTAXDq1/MfXHui3NfPLf7",
+	"ekkLlIyZdzMzWqETOILPc0H0HF29T37mQ7vf5Ac+bm6AW0p+suPmRmej898BALT/drohVgAA",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}
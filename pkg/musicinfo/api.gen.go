@@ -4,14 +4,19 @@
 package musicinfo
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/oapi-codegen/runtime"
 )
 
@@ -277,3 +282,89 @@ func ParseGetInfoResponse(rsp *http.Response) (*GetInfoResponse, error) {
 
 	return response, nil
 }
+
+// Base64 encoded, gzipped, json marshaled Swagger object
+var swaggerSpec = []string{
+
+	"H4sIAAAAAAAC/5RTQW/UPBD9K9Z8n8QlJGkLRYqEVqAi2EPVSnAAdSvkTSaJ22TGtcdNo2r/O7K3sF1Y",
+	"CXGK43nvjefNzCPUPFomJPFQPYKvexx1On5m6s5QtBnin3Vs0YnBFBsM3cYvPujRDggV9CLWV0UxTVM+",
+	"c5CwxrzmsZi01P3i/u1Xb0++65eX518myEBmG0lenKEONhk4HFB7PNOC+7pHp3n5Jj8uy9NDNMEH2cdf",
+	"cK/Wej1nqmF6IWrmoG6JJ7VUPrQtusWKdphaU0L0qJ0aUY2sabGibxxUrUPXS7wM1KBTrR48KutQkDz6",
+	"FX3iSQ1MnVpjyw6TzoCJ0fFiRSnPVsvH61l5DoPSg+l6+Uvwz1qTR3fBOGygutrz68mGbNuV619UXt9g",
+	"LbCJXEMtR6PESLLpPHhTqyW1rD48CDrSg3p3uYQM7tF5wwQVlHmZH0WX2SJpa6CCk7zMTyADq6VPg1D8",
+	"FO4wNSIOiRbDtGyggo8oMUUiOD2ioPNQXT2Cifp3Ad0MGZAe45M6x8HC8zLFBcyeRjI9/ndLDgt5pu6f",
+	"dK4j2FuOfY3x47KMn5pJkFJZ2trB1Kmw4sYz7VYlnv532EIF/xW7XSq2UV8826LUiAZ97YyVrcUXt9Hf",
+	"V9t8+6H3ulGxBPQSMa8PYZb01DqP7h6dQufYxTybzebHAIvMzWraAwAA",
+}
+
+// GetSwagger returns the content of the embedded swagger specification file
+// or error if failed to decode
+func decodeSpec() ([]byte, error) {
+	zipped, err := base64.StdEncoding.DecodeString(strings.Join(swaggerSpec, ""))
+	if err != nil {
+		return nil, fmt.Errorf("error base64 decoding spec: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing spec: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var rawSpec = decodeSpecCached()
+
+// a naive cached of a decoded swagger spec
+func decodeSpecCached() func() ([]byte, error) {
+	data, err := decodeSpec()
+	return func() ([]byte, error) {
+		return data, err
+	}
+}
+
+// Constructs a synthetic filesystem for resolving external references when loading openapi specifications.
+func PathToRawSpec(pathToFile string) map[string]func() ([]byte, error) {
+	res := make(map[string]func() ([]byte, error))
+	if len(pathToFile) > 0 {
+		res[pathToFile] = rawSpec
+	}
+
+	return res
+}
+
+// GetSwagger returns the Swagger specification corresponding to the generated code
+// in this file. The external references of Swagger specification are resolved.
+// The logic of resolving external references is tightly connected to "import-mapping" feature.
+// Externally referenced files must be embedded in the corresponding golang packages.
+// Urls can be supported but this task was out of the scope.
+func GetSwagger() (swagger *openapi3.T, err error) {
+	resolvePath := PathToRawSpec("")
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	loader.ReadFromURIFunc = func(loader *openapi3.Loader, url *url.URL) ([]byte, error) {
+		pathToFile := url.String()
+		pathToFile = path.Clean(pathToFile)
+		getSpec, ok := resolvePath[pathToFile]
+		if !ok {
+			err1 := fmt.Errorf("path not found: %s", pathToFile)
+			return nil, err1
+		}
+		return getSpec()
+	}
+	var specData []byte
+	specData, err = rawSpec()
+	if err != nil {
+		return
+	}
+	swagger, err = loader.LoadFromData(specData)
+	if err != nil {
+		return
+	}
+	return
+}